@@ -0,0 +1,149 @@
+// Package bench 基准测试使用的文法与输入, 以及比较两次 go test -bench 输出的工具
+//
+//	go test ./bench -run '^$' -bench . -benchmem -count 5 > old.txt
+//	# 修改之后
+//	go test ./bench -run '^$' -bench . -benchmem -count 5 > new.txt
+//	go run ./cmd/benchcmp old.txt new.txt
+package bench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+// ----------------------------------------------------------------
+// Grammars
+// ----------------------------------------------------------------
+
+func tok(p Parser) Parser    { return Left(p, Spaces) }
+func sym(r rune) Parser      { return tok(Char(r)) }
+func symStr(s string) Parser { return tok(Str(s)) }
+
+// JSON 解析为 map[string]interface{} []interface{} string float64 bool nil
+func JSON() Parser {
+	Value := NewNamedRule("Value")
+	str := tok(Regex(`"(?:[^"\\]|\\.)*"`)).Map(func(v interface{}) interface{} {
+		s, err := strconv.Unquote(v.(string))
+		if err != nil {
+			return v.(string)[1 : len(v.(string))-1]
+		}
+		return s
+	})
+	num := tok(Regex(`-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?`)).Map(func(v interface{}) interface{} {
+		f, _ := strconv.ParseFloat(v.(string), 64)
+		return f
+	})
+	lit := tok(Regex(`true|false|null`)).Map(func(v interface{}) interface{} {
+		switch v {
+		case "true":
+			return true
+		case "false":
+			return false
+		default:
+			return nil
+		}
+	})
+	member := Seq(str, Right(sym(':'), Value), func(k, v interface{}) interface{} { return [2]interface{}{k, v} })
+	object := Mid(sym('{'), SepBy(member, sym(',')), sym('}')).Map(func(v interface{}) interface{} {
+		m := map[string]interface{}{}
+		for _, kv := range v.([]interface{}) {
+			m[kv.([2]interface{})[0].(string)] = kv.([2]interface{})[1]
+		}
+		return m
+	})
+	array := Mid(sym('['), SepBy(Value, sym(',')), sym(']'))
+	Value.Pattern = Alt(object, array, str, num, lit)
+	return ExpectEof(Right(Spaces, Value))
+}
+
+// JSONInput n 个对象组成的数组
+func JSONInput(n int) string {
+	var b strings.Builder
+	b.WriteString("[\n")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `  {"id": %d, "name": "item \"%d\"", "price": %d.5e-1, "tags": ["a", "b"], "ok": %t, "next": null}`,
+			i, i, i, i%2 == 0)
+	}
+	b.WriteString("\n]\n")
+	return b.String()
+}
+
+// Lisp 解析 s-expression, 结果为 []interface{} 嵌套的 atom
+func Lisp() Parser {
+	SExpr := NewNamedRule("SExpr")
+	comment := Right(Char(';'), SkipMany(NoneOf("\n")))
+	ws := SkipMany(Either(Space, comment))
+	tok := func(p Parser) Parser { return Left(p, ws) }
+	list := Mid(tok(Char('(')), Many(SExpr), tok(Char(')')))
+	quote := Right(tok(Char('\'')), SExpr).Map(func(v interface{}) interface{} { return []interface{}{"quote", v} })
+	str := tok(LitStr)
+	atom := tok(Regex(`[^()'";\s]+`))
+	SExpr.Pattern = Alt(list, quote, str, atom)
+	return ExpectEof(Right(ws, Many1(SExpr)))
+}
+
+// LispInput n 个定义
+func LispInput(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "(define (f%d n) ; comment\n  (if (= n 0) '(1 . \"s%d\") (* n (f%d (- n 1)))))\n", i, i, i)
+	}
+	return b.String()
+}
+
+// Ops10 10 个优先级的二元操作符, 由高到低, ^ 右结合
+var Ops10 = []string{"^", "*", "/", "%", "+", "-", "<<", ">>", "&", "|"}
+
+// Expr10 10 层操作符表的表达式, 结果为数字的个数
+func Expr10() Parser {
+	count := func(op exprparser.Op, l, r interface{}) interface{} { return l.(int) + r.(int) }
+	table := make(exprparser.OperatorTable, len(Ops10))
+	for i, op := range Ops10 {
+		if op == "^" {
+			table[i] = []exprparser.Operator{exprparser.InfixR(symStr(op), count)}
+		} else {
+			table[i] = []exprparser.Operator{exprparser.InfixL(symStr(op), count)}
+		}
+	}
+	Expr := NewNamedRule("Expr")
+	term := Alt(Mid(sym('('), Expr, sym(')')), tok(Digits).Map(func(interface{}) interface{} { return 1 }))
+	Expr.Pattern = exprparser.BuildExpressionParser(table, term)
+	return ExpectEof(Right(Spaces, Expr))
+}
+
+// ExprInput n 个数字, 依次使用所有操作符, 每 5 个数字一组括号
+func ExprInput(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			fmt.Fprintf(&b, " %s ", Ops10[i%len(Ops10)])
+		}
+		if i%5 == 0 && i+1 < n {
+			fmt.Fprintf(&b, "(%d %s ", i, Ops10[(i+3)%len(Ops10)])
+			i++
+			fmt.Fprintf(&b, "%d)", i)
+			continue
+		}
+		fmt.Fprintf(&b, "%d", i)
+	}
+	return b.String()
+}
+
+// AccessLogInput n 行 Common Log Format 访问日志
+func AccessLogInput(n int) string {
+	methods := []string{"GET", "POST", "PUT", "DELETE", "HEAD"}
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "10.0.%d.%d - user%d [10/Oct/2000:13:55:%02d -0700] \"%s /api/v1/items/%d HTTP/1.1\" %d %d\n",
+			i/256%256, i%256, i, i%60, methods[i%len(methods)], i, 200+i%4*100, i*7)
+	}
+	return b.String()
+}
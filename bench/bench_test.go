@@ -0,0 +1,170 @@
+package bench
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goghcrow/lexer"
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/example/peggen/accesslog"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/bitstate"
+	"github.com/goghcrow/parsec/states/bytestate"
+	. "github.com/goghcrow/parsec/states/charstate"
+	"github.com/goghcrow/parsec/states/slicestate"
+	"github.com/goghcrow/parsec/states/tokstate"
+)
+
+var sizes = []int{10, 100, 1000}
+
+// run 每次迭代用 newState 创建新的 State, 计时前先解析一次确认成功
+func run(b *testing.B, p Parser, newState func() State) {
+	if _, err := p.Parse(newState()); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse(newState()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// runSizes 按 sizes 生成输入, 子测试命名为 n=N
+func runSizes(b *testing.B, p Parser, input func(n int) string) {
+	for _, n := range sizes {
+		src := input(n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			run(b, p, func() State { return NewState(src) })
+		})
+	}
+}
+
+func repeat(s string) func(n int) string {
+	return func(n int) string { return strings.Repeat(s, n) }
+}
+
+// ----------------------------------------------------------------
+// Combinators
+// ----------------------------------------------------------------
+
+func BenchmarkCombinator(b *testing.B) {
+	keywords := make([]Parser, 10)
+	for i := range keywords {
+		keywords[i] = Str(fmt.Sprintf("k%d", i))
+	}
+	plus := Char('+').Map(func(interface{}) interface{} {
+		return func(x, y interface{}) interface{} { return x.(int) + y.(int) }
+	})
+	one := Digits.Map(func(interface{}) interface{} { return 1 })
+	numbers := func(sep string) func(n int) string {
+		return func(n int) string {
+			xs := make([]string, n)
+			for i := range xs {
+				xs[i] = fmt.Sprint(i)
+			}
+			return strings.Join(xs, sep)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		p     Parser
+		input func(n int) string
+	}{
+		{"Str", Many(Str("ab")), repeat("ab")},
+		{"Regex", Many(Regex(`[a-z]+\s*`)), repeat("word ")},
+		{"Alt10", Many(Alt(keywords...)), repeat("k0k3k6k9")},
+		{"Many", Many(Char('a')), repeat("a")},
+		{"List", Many(List(Char('a'), Char('b'), Char('c'))), repeat("abc")},
+		{"SepBy", SepBy(Digits, Char(',')), numbers(",")},
+		{"Chainl1", Chainl1(one, plus), numbers("+")},
+		{"Memo", Many(Alt(Left(Memo(Digits), Char('-')), Left(Memo(Digits), Char(';')))), repeat("12345;")},
+		{"NotFollowedBy", Many(Right(NotFollowedBy(Char('b')), AnyChar())), repeat("a")},
+		{"Label", Many(Label(Char('a'), "letter a")), repeat("a")},
+		{"Map", Many(Char('a').Map(func(v interface{}) interface{} { return v })), repeat("a")},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			runSizes(b, ExpectEof(tt.p), tt.input)
+		})
+	}
+}
+
+// ----------------------------------------------------------------
+// States
+// 各 State 上 Many(Any) 读取 n 个 item 的开销, bit 的 n 为 bit 数
+// ----------------------------------------------------------------
+
+func BenchmarkState(b *testing.B) {
+	const (
+		Word lexer.TokenKind = iota + 1
+		Space
+	)
+	lex := lexer.BuildLexer(func(lex *lexer.Lexicon) {
+		lex.Regex(Word, `[a-z]+`)
+		lex.Regex(Space, `\s+`).Skip()
+	})
+	p := ExpectEof(Many(Any))
+	bits := ExpectEof(Many(bitstate.AnyBit))
+
+	for _, n := range []int{16, 128, 1024} {
+		src := strings.Repeat("abcdefgh", n/8)
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = src[i]
+		}
+		toks := lex.MustLex(strings.Repeat("ab ", n))
+		b.Run(fmt.Sprintf("char/n=%d", n), func(b *testing.B) {
+			run(b, p, func() State { return NewState(src) })
+		})
+		b.Run(fmt.Sprintf("byte/n=%d", n), func(b *testing.B) {
+			run(b, p, func() State { return bytestate.NewState(src) })
+		})
+		b.Run(fmt.Sprintf("tok/n=%d", n), func(b *testing.B) {
+			run(b, p, func() State { return tokstate.NewState(toks) })
+		})
+		b.Run(fmt.Sprintf("slice/n=%d", n), func(b *testing.B) {
+			run(b, p, func() State { return slicestate.NewState(items) })
+		})
+		b.Run(fmt.Sprintf("bit/n=%d", n), func(b *testing.B) {
+			run(b, bits, func() State { return bitstate.NewState([]byte(src[:n/8])) })
+		})
+	}
+}
+
+// ----------------------------------------------------------------
+// Grammars
+// ----------------------------------------------------------------
+
+func BenchmarkExpr10(b *testing.B) { runSizes(b, Expr10(), ExprInput) }
+
+func BenchmarkLisp(b *testing.B) { runSizes(b, Lisp(), LispInput) }
+
+func BenchmarkJSON(b *testing.B) { runSizes(b, JSON(), JSONInput) }
+
+// BenchmarkAccessLog 同一份 peg 文法解释执行与 parsecgen 生成代码的对比
+func BenchmarkAccessLog(b *testing.B) {
+	ps := peg.MustLoad(accesslog.Grammar).MustBuild(peg.Options{Primitives: peg.BytePrimitives})
+	for _, n := range sizes {
+		src := AccessLogInput(n)
+		b.Run(fmt.Sprintf("peg/n=%d", n), func(b *testing.B) {
+			run(b, ps.Start, func() State { return bytestate.NewState(src) })
+		})
+		b.Run(fmt.Sprintf("gen/n=%d", n), func(b *testing.B) {
+			p := &accesslog.Parser{}
+			if _, _, err := p.Parse(src); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := p.Parse(src); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ----------------------------------------------------------------
+// Compare
+// 比较两次 go test -bench -benchmem 的输出, 找出性能回退
+// ----------------------------------------------------------------
+
+// Result 一个 benchmark 的结果, -count 多次运行时取平均值
+type Result struct {
+	Name        string // 去掉 -GOMAXPROCS 后缀
+	Runs        int
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+}
+
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Parse 读取 go test -bench 的输出, 忽略非 benchmark 行, 按首次出现的顺序返回
+func Parse(r io.Reader) ([]*Result, error) {
+	var rs []*Result
+	byName := map[string]*Result{}
+	sc := bufio.NewScanner(r)
+	for no := 1; sc.Scan(); no++ {
+		fs := strings.Fields(sc.Text())
+		if len(fs) < 4 || !strings.HasPrefix(fs[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fs[1]); err != nil {
+			continue
+		}
+		name := procsSuffix.ReplaceAllString(fs[0], "")
+		res := byName[name]
+		if res == nil {
+			res = &Result{Name: name}
+			byName[name] = res
+			rs = append(rs, res)
+		}
+		res.Runs++
+		// 值与单位成对出现, e.g. 123 ns/op  45 B/op  6 allocs/op  7.8 MB/s
+		for i := 2; i+1 < len(fs); i += 2 {
+			v, err := strconv.ParseFloat(fs[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value `%s`", no, fs[i])
+			}
+			switch fs[i+1] {
+			case "ns/op":
+				res.NsPerOp += v
+			case "B/op":
+				res.BytesPerOp += v
+			case "allocs/op":
+				res.AllocsPerOp += v
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, res := range rs {
+		n := float64(res.Runs)
+		res.NsPerOp, res.BytesPerOp, res.AllocsPerOp = res.NsPerOp/n, res.BytesPerOp/n, res.AllocsPerOp/n
+	}
+	return rs, nil
+}
+
+// Delta 同名 benchmark 的变化, Ns Bytes Allocs 为变化的百分比
+// 只存在于一侧的 benchmark Old 或 New 为 nil
+type Delta struct {
+	Old, New          *Result
+	Ns, Bytes, Allocs float64
+	Regression        bool // 任意一项增长超过阈值
+}
+
+// Name benchmark 名
+func (d *Delta) Name() string {
+	if d.New != nil {
+		return d.New.Name
+	}
+	return d.Old.Name
+}
+
+// Compare 按名字比较 old 与 new, 增长超过 threshold 百分比 (e.g. 10) 视为回退
+// 从 0 增长 (e.g. 原本不分配内存) 也视为回退, 结果按名字排序
+func Compare(old, new []*Result, threshold float64) []*Delta {
+	byName := map[string]*Delta{}
+	for _, r := range old {
+		byName[r.Name] = &Delta{Old: r}
+	}
+	for _, r := range new {
+		d := byName[r.Name]
+		if d == nil {
+			d = &Delta{}
+			byName[r.Name] = d
+		}
+		d.New = r
+	}
+	ds := make([]*Delta, 0, len(byName))
+	for _, d := range byName {
+		if d.Old != nil && d.New != nil {
+			var ns, bs, as bool
+			d.Ns, ns = change(d.Old.NsPerOp, d.New.NsPerOp, threshold)
+			d.Bytes, bs = change(d.Old.BytesPerOp, d.New.BytesPerOp, threshold)
+			d.Allocs, as = change(d.Old.AllocsPerOp, d.New.AllocsPerOp, threshold)
+			d.Regression = ns || bs || as
+		}
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Name() < ds[j].Name() })
+	return ds
+}
+
+func change(old, new, threshold float64) (float64, bool) {
+	if old == 0 {
+		return 0, new > 0
+	}
+	pct := (new - old) / old * 100
+	return pct, pct > threshold
+}
+
+// Report 输出对比表格, 回退的行以 ! 结尾, 返回是否有回退
+func Report(w io.Writer, ds []*Delta) (bool, error) {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "name\told ns/op\tnew ns/op\tdelta\told B/op\tnew B/op\tdelta\told allocs/op\tnew allocs/op\tdelta\t")
+	regressed := false
+	for _, d := range ds {
+		var cols []string
+		switch {
+		case d.Old == nil:
+			cols = []string{"-", num(d.New.NsPerOp), "new", "-", num(d.New.BytesPerOp), "new", "-", num(d.New.AllocsPerOp), "new"}
+		case d.New == nil:
+			cols = []string{num(d.Old.NsPerOp), "-", "removed", num(d.Old.BytesPerOp), "-", "removed", num(d.Old.AllocsPerOp), "-", "removed"}
+		default:
+			cols = []string{
+				num(d.Old.NsPerOp), num(d.New.NsPerOp), pct(d.Old.NsPerOp, d.New.NsPerOp, d.Ns),
+				num(d.Old.BytesPerOp), num(d.New.BytesPerOp), pct(d.Old.BytesPerOp, d.New.BytesPerOp, d.Bytes),
+				num(d.Old.AllocsPerOp), num(d.New.AllocsPerOp), pct(d.Old.AllocsPerOp, d.New.AllocsPerOp, d.Allocs),
+			}
+		}
+		mark := ""
+		if d.Regression {
+			mark, regressed = "!", true
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Name(), strings.Join(cols, "\t"), mark)
+	}
+	if err := tw.Flush(); err != nil {
+		return regressed, err
+	}
+	// 去掉对齐填充的行尾空格
+	lines := strings.SplitAfter(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \n") + "\n"
+	}
+	_, err := io.WriteString(w, strings.Join(lines[:len(lines)-1], ""))
+	return regressed, err
+}
+
+func num(v float64) string { return strings.TrimSuffix(strconv.FormatFloat(v, 'f', 1, 64), ".0") }
+
+func pct(old, new, delta float64) string {
+	switch {
+	case old == 0 && new == 0:
+		return "~"
+	case old == 0:
+		return "+inf"
+	default:
+		return fmt.Sprintf("%+.2f%%", delta)
+	}
+}
@@ -0,0 +1,64 @@
+// benchcmp 比较两次 go test -bench -benchmem 的输出, 任意一项增长超过阈值时退出码为 1
+//
+//	go test ./bench -run '^$' -bench . -benchmem -count 5 > old.txt
+//	go test ./bench -run '^$' -bench . -benchmem -count 5 > new.txt
+//	benchcmp -threshold 10 old.txt new.txt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/goghcrow/parsec/bench"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 10, "regression threshold in percent")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "usage: benchcmp [-threshold N] old.txt new.txt\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	regressed, err := run(flag.Arg(0), flag.Arg(1), *threshold)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "benchcmp: %s\n", err)
+		os.Exit(2)
+	}
+	if regressed {
+		os.Exit(1)
+	}
+}
+
+func run(oldFile, newFile string, threshold float64) (bool, error) {
+	old, err := parse(oldFile)
+	if err != nil {
+		return false, err
+	}
+	new, err := parse(newFile)
+	if err != nil {
+		return false, err
+	}
+	return bench.Report(os.Stdout, bench.Compare(old, new, threshold))
+}
+
+func parse(file string) ([]*bench.Result, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rs, err := bench.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("%s: no benchmark results", file)
+	}
+	return rs, nil
+}
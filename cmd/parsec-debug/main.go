@@ -0,0 +1,29 @@
+// parsec-debug 单步调试 peg 文法
+//
+//	parsec-debug -peg calc.peg -skip '\s+' -e '1 + 2 * x'
+//	parsec-debug -peg calc.peg -skip '\s+' -e '1 + 2 * x' -export html > trace.html
+//
+// 内置了 bench 中用 Go 构造的示例文法 json lisp expr, e.g.
+//
+//	parsec-debug -grammar lisp -input example/lisp/testdata/fact.lisp
+//
+// 调试自己的 Go 文法: 在自己的 main 中 debugger.Register 之后调用 debugger.Main
+package main
+
+import (
+	"os"
+
+	"github.com/goghcrow/parsec/bench"
+	"github.com/goghcrow/parsec/debugger"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+func init() {
+	debugger.Register("json", debugger.Grammar{Parser: bench.JSON(), NewState: charstate.NewState})
+	debugger.Register("lisp", debugger.Grammar{Parser: bench.Lisp(), NewState: charstate.NewState})
+	debugger.Register("expr", debugger.Grammar{Parser: bench.Expr10(), NewState: charstate.NewState})
+}
+
+func main() {
+	os.Exit(debugger.Main(os.Args[1:], os.Stdin, os.Stdout))
+}
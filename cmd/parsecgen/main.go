@@ -0,0 +1,63 @@
+// parsecgen 把 peg 文法生成为专用的 Go parser, 结果与错误同 peg.Build 解释执行一致
+//
+//	parsecgen -grammar calc.peg -pkg calc -state char -skip '\s+' -o calc_gen.go
+//
+// 一般配合 go:generate 使用
+//
+// 输入只能是 peg 文法 (文本, 或者在 Go 中构造 peg.Grammar 后用 String 输出), peg.Build 把它构造为命名 SyntaxRule 解释执行;
+// 直接用 Alt Many 等组合子手写的文法由闭包组成, 无法遍历, 不能生成, 需要先改写为 peg 文法,
+// e.g. example/peggen/accesslog 的访问日志 parser.
+// 不支持 peg.Options.Terminals 与 tokstate, 只支持 char 与 byte
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goghcrow/parsec/peg"
+)
+
+func main() {
+	grammar := flag.String("grammar", "", "peg grammar file")
+	pkg := flag.String("pkg", "", "package name of generated code")
+	state := flag.String("state", "char", "input state: char or byte")
+	skip := flag.String("skip", "", "regex skipped after each terminal, e.g. \\s+")
+	out := flag.String("o", "", "output file, default stdout")
+	flag.Parse()
+
+	if err := run(*grammar, *pkg, *state, *skip, *out); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "parsecgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(grammar, pkg, state, skip, out string) error {
+	if grammar == "" {
+		return fmt.Errorf("-grammar required")
+	}
+	src, err := ioutil.ReadFile(grammar)
+	if err != nil {
+		return err
+	}
+	g, err := peg.Load(string(src))
+	if err != nil {
+		return fmt.Errorf("%s: %s", grammar, err)
+	}
+	code, err := g.Generate(peg.GenOptions{
+		Package: pkg,
+		State:   state,
+		Skip:    skip,
+		Source:  filepath.Base(grammar),
+	})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return ioutil.WriteFile(out, code, 0644)
+}
@@ -82,7 +82,14 @@ func Try(p Parser) Parser {
 		if err == nil {
 			return v, nil
 		}
+		from := s.Save()
 		s.Restore(pos)
+		// 未消费 state 的失败 (e.g. alternatives 的首个 token 不匹配) 不算回溯
+		if c := ContextOf(s); c != nil && !IsAbort(err) && from.Idx != pos.Idx {
+			if aerr := c.backtrack(s, from, err); aerr != nil {
+				return nil, aerr
+			}
+		}
 		return nil, err
 	})
 }
@@ -107,6 +114,9 @@ func Either(a, b Parser) Parser {
 		if err == nil {
 			return v, nil
 		}
+		if IsAbort(err) {
+			return nil, err
+		}
 		return b.Parse(s)
 	})
 }
@@ -269,6 +279,9 @@ func NotFollowedBy(p Parser) Parser {
 		if err == nil {
 			return nil, Trap(pos, "unexpect `%s`", Show(c))
 		}
+		if IsAbort(err) {
+			return nil, err
+		}
 		s.Restore(pos)
 		return nil, nil
 	})
@@ -290,6 +303,55 @@ func ManyTill(p, end Parser) Parser {
 
 func ExpectEof(p Parser) Parser { return Left(p, Eof) }
 
+// Spanned p 的结果与 p 消耗的范围
+type Spanned struct {
+	Value interface{}
+	Span
+}
+
+// WithSpan 返回 Spanned, 可以用 Span 从 State 取回对应的源码 (e.g. tokstate 的 Text)
+func WithSpan(p Parser) Parser {
+	return parser(func(s State) (interface{}, error) {
+		start := s.Save()
+		v, err := p.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return Spanned{v, Span{start, s.Save()}}, nil
+	})
+}
+
+// ===== Context Combinators =====
+
+// Memo 在单次解析的 Context 中以 (p, pos) 为 key 缓存 p 的结果和剩余状态
+// 可以用来处理有公共前缀的 alternatives 反复回溯 (packrat)
+// State 未实现 Contextual 时等同于 p
+func Memo(p Parser) Parser { return &memoParser{p} }
+
+// Recover p 失败时把错误报告给 Context (见 Context.Errors), 从 p 开始的位置用 skip 跳过出错的部分, 返回 x
+// skip 失败或者 State 未实现 Contextual 时返回 p 的错误
+// 注意: skip 必须消耗 state, 否则在 Many 中会死循环
+// e.g. stmt := Recover(Left(expr, Str(";")), ManyTill(Any, Str(";")), badStmt)
+func Recover(p, skip Parser, x interface{}) Parser {
+	return parser(func(s State) (interface{}, error) {
+		pos := s.Save()
+		v, err := p.Parse(s)
+		if err == nil || IsAbort(err) {
+			return v, err
+		}
+		c := ContextOf(s)
+		if c == nil {
+			return nil, err
+		}
+		s.Restore(pos)
+		if _, serr := skip.Parse(s); serr != nil {
+			return nil, err
+		}
+		c.Report(err)
+		return x, nil
+	})
+}
+
 // ===== Debug Combinators =====
 
 // Label p 失败且未消费 state, 会用 msg 替换错误信息, 其他行为与 P 相同
@@ -299,7 +361,7 @@ func Label(p Parser, fmt string, a ...interface{}) Parser {
 		pos := s.Save()
 		v, err := p.Parse(s)
 		if err != nil {
-			if pos == s.Save() {
+			if pos == s.Save() && !IsAbort(err) {
 				return nil, Trap(pos, fmt, a...)
 			} else {
 				return nil, err
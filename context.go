@@ -0,0 +1,274 @@
+package parsec
+
+import (
+	"context"
+	"fmt"
+)
+
+// ----------------------------------------------------------------
+// Per-parse Context
+// parser 本身不保存状态, 可以被多个 goroutine 共享
+// 单次解析的可变状态 (取消, 缓存, trace, 错误收集) 都放在 Context 中, 通过 State 传递
+// ----------------------------------------------------------------
+
+func NewContext(ctx context.Context) *Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Context{Context: ctx}
+}
+
+type Context struct {
+	context.Context
+
+	// Tracer 跟踪 SyntaxRule 的进入与退出, nil 不跟踪
+	Tracer Tracer
+
+	// Limits 资源限制, 超出时中止解析
+	Limits
+
+	memo      map[memoKey]memoItem
+	scope     int // 当前 Memo 作用域, 见 MemoScope
+	nextScope int
+	errs      []error
+	abort     error // 一旦中止, 之后所有 parser 都直接返回该错误
+
+	rule       *SyntaxRule // 当前所在的 SyntaxRule
+	depth      int
+	steps      int
+	backtracks int
+}
+
+// Limits 单次解析的资源限制, 0 表示不限制
+// 超时与取消通过 context.Context 控制
+type Limits struct {
+	MaxDepth      int // SyntaxRule 的最大嵌套深度
+	MaxSteps      int // parser 的最大调用次数
+	MaxBacktracks int // 最大回溯次数, 只计算消费了 state 之后失败的 Try
+}
+
+// Contextual 可以携带 Context 的 State, 内置 State 都已实现
+// 自定义 State 可以嵌入 ContextHolder
+type Contextual interface {
+	Context() *Context
+	SetContext(*Context)
+}
+
+type ContextHolder struct{ ctx *Context }
+
+func (h *ContextHolder) Context() *Context     { return h.ctx }
+func (h *ContextHolder) SetContext(c *Context) { h.ctx = c }
+
+// ContextOf 获取 State 携带的 Context, 没有则创建一个, State 未实现 Contextual 返回 nil
+func ContextOf(s State) *Context {
+	cs, ok := s.(Contextual)
+	if !ok {
+		return nil
+	}
+	c := cs.Context()
+	if c == nil {
+		c = NewContext(nil)
+		cs.SetContext(c)
+	}
+	return c
+}
+
+// Run 在 Context c 中用 p 解析 s
+// 解析被中止时 (e.g. ctx 取消) 返回 *AbortError
+func Run(c *Context, p Parser, s State) (interface{}, error) {
+	if cs, ok := s.(Contextual); ok {
+		cs.SetContext(c)
+	}
+	v, err := p.Parse(s)
+	if c.abort != nil {
+		return nil, c.abort
+	}
+	return v, err
+}
+
+// ----------------------------------------------------------------
+// Abort
+// ----------------------------------------------------------------
+
+// AbortError 解析被中止, 不会被 Either Choice Option 等组合子吞掉
+// Err 为 ctx.Err(), *LimitError 或者 Context.Abort 传入的错误 (e.g. 词法错误), Pos 为中止的位置
+type AbortError struct {
+	Pos
+	Err error
+}
+
+func (e *AbortError) Error() string { return fmt.Sprintf("parse aborted: %s in %s", e.Err, e.Pos) }
+func (e *AbortError) Unwrap() error { return e.Err }
+
+// LimitError 超出 Limits
+type LimitError struct {
+	Limit string // depth steps backtracks
+	Max   int
+	Rule  string // 超出时所在的 SyntaxRule
+}
+
+func (e *LimitError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("exceed max %s %d", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("exceed max %s %d in rule %s", e.Limit, e.Max, e.Rule)
+}
+
+func IsAbort(err error) bool {
+	_, ok := err.(*AbortError)
+	return ok
+}
+
+// Abort 在 pos 处中止解析, 之后所有 parser 都返回该 *AbortError
+// 用于 parser 之外 (e.g. State 的词法错误) 无法继续解析的错误, 已经中止时返回原来的错误
+func (c *Context) Abort(pos Pos, err error) error {
+	if c.abort == nil {
+		c.abort = &AbortError{pos, err}
+	}
+	return c.abort
+}
+
+func (c *Context) checkAbort(s State) error {
+	if c.abort != nil {
+		return c.abort
+	}
+	select {
+	case <-c.Done():
+		c.abort = &AbortError{s.Save(), c.Err()}
+		return c.abort
+	default:
+		return nil
+	}
+}
+
+func (c *Context) limit(s State, limit string, max int) error {
+	e := &LimitError{Limit: limit, Max: max}
+	if c.rule != nil {
+		e.Rule = c.rule.Name
+	}
+	c.abort = &AbortError{s.Save(), e}
+	return c.abort
+}
+
+// step 每次 parser 调用计数, 每 1024 次检查一次 ctx 是否取消或超时
+func (c *Context) step(s State) error {
+	if c.abort != nil {
+		return c.abort
+	}
+	c.steps++
+	if c.MaxSteps > 0 && c.steps > c.MaxSteps {
+		return c.limit(s, "steps", c.MaxSteps)
+	}
+	if c.steps&1023 == 0 {
+		return c.checkAbort(s)
+	}
+	return nil
+}
+
+func (c *Context) backtrack(s State, from Pos, err error) error {
+	if bt, ok := c.Tracer.(BacktrackTracer); ok {
+		bt.Backtrack(c.rule, from, s.Save(), err)
+	}
+	c.backtracks++
+	if c.MaxBacktracks > 0 && c.backtracks > c.MaxBacktracks {
+		return c.limit(s, "backtracks", c.MaxBacktracks)
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------
+// Trace
+// ----------------------------------------------------------------
+
+// Tracer 跟踪 SyntaxRule 的调用, pos 分别为进入与退出时的位置
+type Tracer interface {
+	Enter(r *SyntaxRule, pos Pos)
+	Exit(r *SyntaxRule, pos Pos, v interface{}, err error)
+}
+
+// BacktrackTracer Tracer 可选实现, 跟踪 Try 的回溯, r 为所在的 SyntaxRule (可能为 nil),
+// 从出错的位置 from 恢复到 to, from 与 to 相同 (未消费 state) 时不通知
+type BacktrackTracer interface {
+	Backtrack(r *SyntaxRule, from, to Pos, err error)
+}
+
+func (c *Context) parseRule(r *SyntaxRule, s State) (interface{}, error) {
+	if err := c.checkAbort(s); err != nil {
+		return nil, err
+	}
+	outer := c.rule
+	c.rule = r
+	c.depth++
+	defer func() { c.rule = outer; c.depth-- }()
+	if c.MaxDepth > 0 && c.depth > c.MaxDepth {
+		return nil, c.limit(s, "depth", c.MaxDepth)
+	}
+	if c.Tracer == nil {
+		return r.Pattern.Parse(s)
+	}
+	c.Tracer.Enter(r, s.Save())
+	v, err := r.Pattern.Parse(s)
+	c.Tracer.Exit(r, s.Save(), v, err)
+	return v, err
+}
+
+// ----------------------------------------------------------------
+// Memo
+// ----------------------------------------------------------------
+
+type memoKey struct {
+	p     *memoParser
+	idx   int
+	scope int
+}
+
+type memoItem struct {
+	v    interface{}
+	err  error
+	rest Pos
+}
+
+type memoParser struct{ p Parser }
+
+func (m *memoParser) Parse(s State) (interface{}, error) {
+	c := ContextOf(s)
+	if c == nil {
+		return m.p.Parse(s)
+	}
+	k := memoKey{m, s.Save().Idx, c.scope}
+	if it, ok := c.memo[k]; ok {
+		s.Restore(it.rest)
+		return it.v, it.err
+	}
+	v, err := m.p.Parse(s)
+	if IsAbort(err) {
+		return nil, err
+	}
+	if c.memo == nil {
+		c.memo = map[memoKey]memoItem{}
+	}
+	c.memo[k] = memoItem{v, err, s.Save()}
+	return v, err
+}
+func (m *memoParser) Map(f func(v interface{}) interface{}) Parser { return Map(m, f) }
+func (m *memoParser) FlatMap(f func(v interface{}) Parser) Parser  { return FlatMap(m, f) }
+
+// MemoScope 在新的 Memo 作用域中执行 f, 作用域内外 (以及每次调用之间) 的 Memo 缓存互不可见
+// 用于 State 临时改变可见输入的情况, 相同位置的结果不能共用 (e.g. bytestate.LengthPrefixed 截断输入)
+func (c *Context) MemoScope(f func()) {
+	outer := c.scope
+	c.nextScope++
+	c.scope = c.nextScope
+	defer func() { c.scope = outer }()
+	f()
+}
+
+// ----------------------------------------------------------------
+// Errors
+// ----------------------------------------------------------------
+
+// Report 收集错误, 配合 Recover 在出错后继续解析
+func (c *Context) Report(err error) { c.errs = append(c.errs, err) }
+
+// Errors 返回收集到的错误
+func (c *Context) Errors() []error { return c.errs }
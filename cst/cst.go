@@ -0,0 +1,229 @@
+package cst
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Concrete Syntax Tree
+// 通过 Tracer 记录命名 SyntaxRule 的匹配范围, 与 Map 等用户函数无关
+// 节点之间的空隙切分为 token 与 trivia (空白, 注释) 叶子保留, 输出与输入完全一致
+// State 实现 Tokenized (e.g. tokstate) 时按其 token 边界切分,
+// 否则 (e.g. charstate bytestate) 连续的空白为 trivia, 连续的非空白为一个 token
+// ----------------------------------------------------------------
+
+const (
+	KindRoot   = "#root"   // Parse 返回的根节点, 覆盖完整输入
+	KindToken  = "#token"  // token 叶子
+	KindTrivia = "#trivia" // trivia 叶子
+)
+
+type Node struct {
+	Kind string // SyntaxRule.Name, KindRoot, KindToken 或 KindTrivia
+	Span
+	Text     string // 仅叶子
+	Children []*Node
+	Parent   *Node
+}
+
+func (n *Node) IsLeaf() bool   { return n.Kind == KindToken || n.Kind == KindTrivia }
+func (n *Node) IsTrivia() bool { return n.Kind == KindTrivia }
+
+// String 还原节点对应的源码
+func (n *Node) String() string {
+	var b strings.Builder
+	n.write(&b)
+	return b.String()
+}
+
+func (n *Node) write(b *strings.Builder) {
+	if n.IsLeaf() {
+		b.WriteString(n.Text)
+		return
+	}
+	for _, c := range n.Children {
+		c.write(b)
+	}
+}
+
+// Dump 缩进展示树结构, 用于调试与测试
+func (n *Node) Dump() string {
+	var b strings.Builder
+	n.dump(&b, 0)
+	return b.String()
+}
+
+func (n *Node) dump(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	if n.IsLeaf() {
+		_, _ = fmt.Fprintf(b, "%s %q\n", n.Kind, n.Text)
+		return
+	}
+	_, _ = fmt.Fprintf(b, "%s %d-%d\n", n.Kind, n.Start.Idx, n.End.Idx)
+	for _, c := range n.Children {
+		c.dump(b, depth+1)
+	}
+}
+
+// Find 深度优先查找第一个 kind 节点
+func (n *Node) Find(kind string) *Node {
+	if n.Kind == kind {
+		return n
+	}
+	for _, c := range n.Children {
+		if x := c.Find(kind); x != nil {
+			return x
+		}
+	}
+	return nil
+}
+
+// Parse 以 CST 模式用 p 解析 s, 返回 p 的结果以及 CST
+// s 必须实现 Lossless 与 Contextual, 已有的 Tracer 会继续收到调用
+// 注意: Memo 命中缓存时不经过 Tracer, 对应的子树退化为叶子, 输出仍与输入一致
+func Parse(p Parser, s State) (interface{}, *Node, error) {
+	src, ok := s.(Lossless)
+	if !ok {
+		panic(fmt.Sprintf("cst: %T does not implement parsec.Lossless", s))
+	}
+	c := ContextOf(s)
+	if c == nil {
+		panic(fmt.Sprintf("cst: %T does not implement parsec.Contextual", s))
+	}
+	start := s.Save()
+	b := &builder{next: c.Tracer, stack: []*Node{{Kind: KindRoot}}}
+	c.Tracer = b
+	defer func() { c.Tracer = b.next }()
+
+	v, err := Run(c, p, s)
+
+	root := b.stack[0]
+	root.Span = Span{Start: start, End: src.End()}
+	if err != nil {
+		root.Children = nil
+	} else {
+		root.Children = drop(root.Children, s.Save().Idx)
+	}
+	end := s.Save()
+	fill(root, s)
+	s.Restore(end)
+	return v, root, err
+}
+
+type builder struct {
+	next  Tracer
+	stack []*Node // 正在匹配的节点, stack[0] 为根
+}
+
+func (b *builder) Enter(r *SyntaxRule, pos Pos) {
+	if r.Name != "" {
+		b.stack = append(b.stack, &Node{Kind: r.Name, Span: Span{Start: pos}})
+	}
+	if b.next != nil {
+		b.next.Enter(r, pos)
+	}
+}
+
+func (b *builder) Exit(r *SyntaxRule, pos Pos, v interface{}, err error) {
+	if b.next != nil {
+		b.next.Exit(r, pos, v, err)
+	}
+	if r.Name == "" {
+		return
+	}
+	n := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	if err != nil {
+		return
+	}
+	n.End = pos
+	n.Children = drop(n.Children, pos.Idx)
+	parent := b.stack[len(b.stack)-1]
+	// 之前匹配但结束位置在 n 之后的兄弟节点已经被回溯
+	parent.Children = append(drop(parent.Children, n.Start.Idx), n)
+}
+
+// Backtrack 当前节点中从恢复位置 to 开始的子节点属于被放弃的分支
+func (b *builder) Backtrack(r *SyntaxRule, from, to Pos, err error) {
+	if bt, ok := b.next.(BacktrackTracer); ok {
+		bt.Backtrack(r, from, to, err)
+	}
+	n := b.stack[len(b.stack)-1]
+	for len(n.Children) > 0 && n.Children[len(n.Children)-1].Start.Idx >= to.Idx {
+		n.Children = n.Children[:len(n.Children)-1]
+	}
+}
+
+// drop 去掉结束位置超过 idx 的节点 (回溯留下的)
+func drop(xs []*Node, idx int) []*Node {
+	for len(xs) > 0 && xs[len(xs)-1].End.Idx > idx {
+		xs = xs[:len(xs)-1]
+	}
+	return xs
+}
+
+// fill 用 token 与 trivia 叶子填充子节点之间的空隙, 并设置 Parent
+func fill(n *Node, s State) {
+	var xs []*Node
+	text := func(from, to Pos) {
+		if from.Idx >= to.Idx {
+			return
+		}
+		for _, p := range pieces(s, from, to) {
+			kind := KindToken
+			if p.Trivia {
+				kind = KindTrivia
+			}
+			xs = append(xs, &Node{Kind: kind, Span: p.Span, Text: p.Text, Parent: n})
+		}
+	}
+	at := n.Start
+	for _, c := range n.Children {
+		text(at, c.Start)
+		fill(c, s)
+		c.Parent = n
+		xs = append(xs, c)
+		at = c.End
+	}
+	text(at, n.End)
+	n.Children = xs
+}
+
+// pieces 切分 [from, to), 没有实现 Tokenized 时用 Next 前进每一段的长度得到各自的 Span
+func pieces(s State, from, to Pos) []Piece {
+	src := s.(Lossless)
+	if t, ok := src.(Tokenized); ok {
+		return t.Pieces(from, to)
+	}
+	var xs []Piece
+	text := src.Slice(from, to)
+	s.Restore(from)
+	for len(text) > 0 {
+		space := isSpace(text)
+		i := strings.IndexFunc(text, func(r rune) bool { return unicode.IsSpace(r) != space })
+		if i < 0 {
+			i = len(text)
+		}
+		start := s.Save()
+		for n := 0; n < i; {
+			prev := s.Save()
+			if _, ok := s.Next(); !ok {
+				break
+			}
+			n += len(src.Slice(prev, s.Save()))
+		}
+		xs = append(xs, Piece{Text: text[:i], Trivia: space, Span: Span{Start: start, End: s.Save()}})
+		text = text[i:]
+	}
+	return xs
+}
+
+func isSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
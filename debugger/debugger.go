@@ -0,0 +1,299 @@
+// Package debugger 基于 Tracer 单步调试文法: 在命名 SyntaxRule 的进入与退出处暂停,
+// 支持按规则名或位置设置断点, 查看当前位置, 剩余输入, 规则栈与回溯历史
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// Breakpoint 进入名为 Rule 的规则, 或者在 Pos (Pos.Idx, 从 0 开始) 进入任意规则时暂停
+type Breakpoint struct {
+	Rule string
+	Pos  int // Rule 为空时有效
+}
+
+func (b Breakpoint) String() string {
+	if b.Rule != "" {
+		return b.Rule
+	}
+	return "@" + strconv.Itoa(b.Pos+1) // 同 Pos.String 从 1 开始显示
+}
+
+// Backtrack 一次回溯
+type Backtrack struct {
+	Rule     string
+	From, To Pos
+	Err      error
+}
+
+func (b Backtrack) String() string {
+	return fmt.Sprintf("%s: %s -> %s: %s", ruleName(b.Rule), b.From, b.To, b.Err)
+}
+
+// MaxHistory 保留的回溯历史条数
+const MaxHistory = 1000
+
+type mode int
+
+const (
+	modeStep     mode = iota // 每个事件都暂停
+	modeNext                 // 跳过更深的事件
+	modeContinue             // 只在断点暂停
+	modeQuit                 // 不再暂停, 等待解析中止
+)
+
+type frame struct {
+	rule string
+	pos  Pos
+}
+
+type Debugger struct {
+	Breakpoints []Breakpoint
+	History     []Backtrack
+
+	in     *bufio.Scanner
+	out    io.Writer
+	state  State
+	stack  []frame
+	mode   mode
+	depth  int // modeNext 暂停的深度
+	last   string
+	cancel context.CancelFunc
+}
+
+// New 从 in 读取命令, 输出到 out
+func New(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{in: bufio.NewScanner(in), out: out}
+}
+
+// Run 在调试器中用 p 解析 s, 开始时暂停在第一个事件
+// s 实现 Lossless 时可以查看剩余输入
+func (d *Debugger) Run(p Parser, s State) (interface{}, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := NewContext(ctx)
+	c.Tracer = d
+	d.cancel, d.state, d.stack, d.mode = cancel, s, nil, modeStep
+	d.printf("type `help` for commands\n")
+	v, err := Run(c, p, s)
+	if err != nil {
+		d.printf("error: %s\n", err)
+	} else {
+		d.printf("result: %s at %s\n", Show(v), s.Save())
+	}
+	return v, err
+}
+
+func (d *Debugger) Enter(r *SyntaxRule, pos Pos) {
+	d.stack = append(d.stack, frame{r.Name, pos})
+	if d.hitBreakpoint(r.Name, pos) || d.stop() {
+		d.printf("enter %s at %s\n", ruleName(r.Name), pos)
+		d.prompt()
+	}
+}
+
+func (d *Debugger) Exit(r *SyntaxRule, pos Pos, v interface{}, err error) {
+	if d.stop() {
+		if err != nil {
+			d.printf("exit %s at %s: %s\n", ruleName(r.Name), pos, err)
+		} else {
+			d.printf("exit %s at %s: %s\n", ruleName(r.Name), pos, Show(v))
+		}
+		d.prompt()
+	}
+	d.stack = d.stack[:len(d.stack)-1]
+}
+
+func (d *Debugger) Backtrack(r *SyntaxRule, from, to Pos, err error) {
+	b := Backtrack{From: from, To: to, Err: err}
+	if r != nil {
+		b.Rule = r.Name
+	}
+	if len(d.History) == MaxHistory {
+		d.History = d.History[1:]
+	}
+	d.History = append(d.History, b)
+	if d.mode == modeStep {
+		d.printf("backtrack %s\n", b) // 回溯很频繁, 只输出不暂停
+	}
+}
+
+func (d *Debugger) hitBreakpoint(rule string, pos Pos) bool {
+	if d.mode == modeQuit {
+		return false
+	}
+	for _, b := range d.Breakpoints {
+		if b.Rule != "" && b.Rule == rule || b.Rule == "" && b.Pos == pos.Idx {
+			d.printf("breakpoint %s\n", b)
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Debugger) stop() bool {
+	switch d.mode {
+	case modeStep:
+		return true
+	case modeNext:
+		return len(d.stack) <= d.depth
+	default:
+		return false
+	}
+}
+
+// prompt 读取并执行命令, 直到继续解析
+func (d *Debugger) prompt() {
+	for {
+		d.printf("(parsec) ")
+		if !d.in.Scan() {
+			d.quit()
+			return
+		}
+		line := strings.TrimSpace(d.in.Text())
+		if line == "" {
+			line = d.last // 空行重复上一条命令
+		}
+		d.last = line
+		if d.exec(strings.Fields(line)) {
+			return
+		}
+	}
+}
+
+// exec 执行命令, 返回是否继续解析
+func (d *Debugger) exec(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "s", "step":
+		d.mode = modeStep
+		return true
+	case "n", "next":
+		d.mode, d.depth = modeNext, len(d.stack)
+		return true
+	case "c", "continue":
+		d.mode = modeContinue
+		return true
+	case "q", "quit":
+		d.quit()
+		return true
+	case "b", "break":
+		d.breakCmd(args[1:])
+	case "d", "delete":
+		d.deleteCmd(args[1:])
+	case "p", "pos":
+		d.posCmd()
+	case "bt", "where":
+		for i := len(d.stack) - 1; i >= 0; i-- {
+			d.printf("#%d %s at %s\n", len(d.stack)-1-i, ruleName(d.stack[i].rule), d.stack[i].pos)
+		}
+	case "h", "history":
+		d.historyCmd(args[1:])
+	case "help":
+		d.printf(help)
+	default:
+		d.printf("unknown command `%s`, type `help` for commands\n", args[0])
+	}
+	return false
+}
+
+const help = `s, step          stop at next rule enter or exit, backtracks are printed
+n, next          step over the rules called by current rule
+c, continue      run until breakpoint
+b, break         list breakpoints
+b RULE           break on entering RULE
+b @POS           break on entering any rule at POS (as shown in pos N)
+d, delete [N]    delete all breakpoints or breakpoint N
+p, pos           show current position and remaining input
+bt, where        show rule stack
+h, history [N]   show last N (default 10) backtracks
+q, quit          abort the parse
+`
+
+func (d *Debugger) breakCmd(args []string) {
+	if len(args) == 0 {
+		for i, b := range d.Breakpoints {
+			d.printf("%d %s\n", i+1, b)
+		}
+		return
+	}
+	b := Breakpoint{Rule: args[0]}
+	if strings.HasPrefix(args[0], "@") {
+		n, err := strconv.Atoi(args[0][1:])
+		if err != nil || n < 1 {
+			d.printf("invalid position `%s`\n", args[0])
+			return
+		}
+		b = Breakpoint{Pos: n - 1}
+	}
+	d.Breakpoints = append(d.Breakpoints, b)
+	d.printf("breakpoint %d %s\n", len(d.Breakpoints), b)
+}
+
+func (d *Debugger) deleteCmd(args []string) {
+	if len(args) == 0 {
+		d.Breakpoints = nil
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(d.Breakpoints) {
+		d.printf("no breakpoint `%s`\n", args[0])
+		return
+	}
+	d.Breakpoints = append(d.Breakpoints[:n-1], d.Breakpoints[n:]...)
+}
+
+func (d *Debugger) posCmd() {
+	pos := d.state.Save()
+	d.printf("%s\n", pos)
+	if ll, ok := d.state.(Lossless); ok {
+		d.printf("rest %s\n", truncate(ll.Slice(pos, ll.End()), 60))
+	}
+}
+
+func (d *Debugger) historyCmd(args []string) {
+	n := 10
+	if len(args) > 0 {
+		if i, err := strconv.Atoi(args[0]); err == nil && i > 0 {
+			n = i
+		}
+	}
+	from := len(d.History) - n
+	if from < 0 {
+		from = 0
+	}
+	for _, b := range d.History[from:] {
+		d.printf("%s\n", b)
+	}
+}
+
+func (d *Debugger) quit() {
+	d.mode = modeQuit
+	d.cancel()
+}
+
+func (d *Debugger) printf(f string, a ...interface{}) { _, _ = fmt.Fprintf(d.out, f, a...) }
+
+func ruleName(name string) string {
+	if name == "" {
+		return "<anonymous>"
+	}
+	return name
+}
+
+func truncate(s string, n int) string {
+	rs := []rune(s)
+	if len(rs) > n {
+		return strconv.Quote(string(rs[:n])) + "..."
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,151 @@
+package debugger
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/bytestate"
+	"github.com/goghcrow/parsec/states/charstate"
+	"github.com/goghcrow/parsec/trace"
+)
+
+// Grammar 用 Go 构造的文法, 注册后可以通过 -grammar 调试
+type Grammar struct {
+	Parser   Parser
+	NewState func(src string) State
+}
+
+var grammars = map[string]Grammar{}
+
+// Register 注册 Go 文法, 一般在 init 中调用, 之后在自己的 main 中调用 Main
+func Register(name string, g Grammar) {
+	if _, ok := grammars[name]; ok {
+		panic(fmt.Sprintf("grammar %s registered twice", name))
+	}
+	grammars[name] = g
+}
+
+// Main parsec-debug 命令的实现, 命令从 in 读取, 返回 exit code
+//
+//	parsec-debug -peg calc.peg -skip '\s+' -e '1 + 2'
+//	parsec-debug -grammar lisp -input example/lisp/testdata/fact.lisp
+//	parsec-debug -peg calc.peg -e '1 + 2' -export html > trace.html
+func Main(args []string, in io.Reader, out io.Writer) int {
+	fs := flag.NewFlagSet("parsec-debug", flag.ContinueOnError)
+	fs.SetOutput(out)
+	pegFile := fs.String("peg", "", "peg grammar file")
+	state := fs.String("state", "char", "input state of peg grammar: char or byte")
+	skip := fs.String("skip", "", "regex skipped after each terminal of peg grammar, e.g. \\s+")
+	name := fs.String("grammar", "", "registered grammar: "+strings.Join(registered(), " "))
+	inputFile := fs.String("input", "", "input file")
+	input := fs.String("e", "", "input text")
+	export := fs.String("export", "", "write parse trace as dot, json or html instead of debugging")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	src := *input
+	if *inputFile != "" {
+		b, err := ioutil.ReadFile(*inputFile)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return 1
+		}
+		src = string(b)
+	}
+
+	var g Grammar
+	switch {
+	case *pegFile != "":
+		var err error
+		if g, err = loadPEG(*pegFile, *state, *skip); err != nil {
+			fmt.Fprintf(out, "%s: %s\n", *pegFile, err)
+			return 1
+		}
+	case *name != "":
+		var ok bool
+		if g, ok = grammars[*name]; !ok {
+			fmt.Fprintf(out, "unknown grammar %s\n", *name)
+			return 1
+		}
+	default:
+		fmt.Fprintln(out, "-peg or -grammar required")
+		fs.Usage()
+		return 2
+	}
+
+	if *export != "" {
+		return exportTrace(out, *export, g, src)
+	}
+	if _, err := New(in, out).Run(g.Parser, g.NewState(src)); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func exportTrace(out io.Writer, format string, g Grammar, src string) int {
+	t, _, _ := trace.Record(g.Parser, g.NewState(src))
+	var err error
+	switch format {
+	case "dot":
+		err = t.WriteDOT(out)
+	case "json":
+		err = t.WriteJSON(out)
+	case "html":
+		err = t.WriteHTML(out)
+	default:
+		err = fmt.Errorf("unknown export format %s", format)
+	}
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	return 0
+}
+
+func loadPEG(file, state, skip string) (Grammar, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Grammar{}, err
+	}
+	g, err := peg.Load(string(b))
+	if err != nil {
+		return Grammar{}, err
+	}
+	opts := peg.Options{}
+	var newState func(string) State
+	switch state {
+	case "char":
+		opts.Primitives, newState = peg.CharPrimitives, charstate.NewState
+		if skip != "" {
+			opts.Skip = Optional(charstate.Regex(skip))
+		}
+	case "byte":
+		opts.Primitives, newState = peg.BytePrimitives, bytestate.NewState
+		if skip != "" {
+			opts.Skip = Optional(bytestate.Regex(skip))
+		}
+	default:
+		return Grammar{}, fmt.Errorf("unknown state %s", state)
+	}
+	ps, err := g.Build(opts)
+	if err != nil {
+		return Grammar{}, err
+	}
+	return Grammar{Parser: ps.Start, NewState: newState}, nil
+}
+
+func registered() []string {
+	names := make([]string, 0, len(grammars))
+	for name := range grammars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,111 @@
+package example
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goghcrow/parsec/bench"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestBenchGrammars(t *testing.T) {
+	v, err := bench.JSON().Parse(charstate.NewState(bench.JSONInput(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		map[string]interface{}{"id": 0.0, "name": `item "0"`, "price": 0.05, "tags": []interface{}{"a", "b"}, "ok": true, "next": nil},
+		map[string]interface{}{"id": 1.0, "name": `item "1"`, "price": 0.15, "tags": []interface{}{"a", "b"}, "ok": false, "next": nil},
+	}
+	if !reflect.DeepEqual(v, expect) {
+		t.Errorf("expect %v actual %v", expect, v)
+	}
+
+	v, err = bench.Lisp().Parse(charstate.NewState(bench.LispInput(3)))
+	if err != nil || len(v.([]interface{})) != 3 {
+		t.Errorf("unexpected %v %v", v, err)
+	}
+
+	for _, n := range []int{1, 2, 10, 37} {
+		v, err = bench.Expr10().Parse(charstate.NewState(bench.ExprInput(n)))
+		if err != nil || v != n {
+			t.Errorf("n=%d: expect %d actual %v %v", n, n, v, err)
+		}
+	}
+}
+
+const oldBench = `goos: linux
+goarch: amd64
+pkg: github.com/goghcrow/parsec/bench
+BenchmarkJSON/n=10-8     	    1000	    600000 ns/op	  200000 B/op	    7700 allocs/op
+BenchmarkJSON/n=10-8     	    1000	    700000 ns/op	  200000 B/op	    7700 allocs/op
+BenchmarkLisp/n=10-8     	    1000	   1000000 ns/op	  400000 B/op	   16000 allocs/op
+BenchmarkState/tok/n=16-8	  100000	     10000 ns/op	       0 B/op	       0 allocs/op
+BenchmarkRemoved-8       	  100000	     10000 ns/op	       0 B/op	       0 allocs/op
+--- FAIL: BenchmarkBroken
+PASS
+ok  	github.com/goghcrow/parsec/bench	3.000s
+`
+
+const newBench = `BenchmarkJSON/n=10-8     	    1000	    660000 ns/op	  200000 B/op	    7700 allocs/op
+BenchmarkLisp/n=10-8     	    1000	    900000 ns/op	  480000 B/op	   16000 allocs/op
+BenchmarkState/tok/n=16-8	  100000	     10000 ns/op	      16 B/op	       1 allocs/op
+BenchmarkAdded-8         	  100000	     10000 ns/op	       0 B/op	       0 allocs/op
+`
+
+func TestBenchCompare(t *testing.T) {
+	old, err := bench.Parse(strings.NewReader(oldBench))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectOld := []*bench.Result{
+		{Name: "BenchmarkJSON/n=10", Runs: 2, NsPerOp: 650000, BytesPerOp: 200000, AllocsPerOp: 7700},
+		{Name: "BenchmarkLisp/n=10", Runs: 1, NsPerOp: 1000000, BytesPerOp: 400000, AllocsPerOp: 16000},
+		{Name: "BenchmarkState/tok/n=16", Runs: 1, NsPerOp: 10000},
+		{Name: "BenchmarkRemoved", Runs: 1, NsPerOp: 10000},
+	}
+	if !reflect.DeepEqual(old, expectOld) {
+		t.Fatalf("expect %v actual %v", expectOld, old)
+	}
+	new, err := bench.Parse(strings.NewReader(newBench))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		regression bool
+	}{
+		{"BenchmarkAdded", false},
+		{"BenchmarkJSON/n=10", false},     // +1.54% ns
+		{"BenchmarkLisp/n=10", true},      // -10% ns, +20% B
+		{"BenchmarkRemoved", false},       //
+		{"BenchmarkState/tok/n=16", true}, // 从 0 开始分配
+	}
+	ds := bench.Compare(old, new, 10)
+	if len(ds) != len(tests) {
+		t.Fatalf("expect %d deltas actual %d", len(tests), len(ds))
+	}
+	for i, tt := range tests {
+		if ds[i].Name() != tt.name || ds[i].Regression != tt.regression {
+			t.Errorf("expect %s %v actual %s %v", tt.name, tt.regression, ds[i].Name(), ds[i].Regression)
+		}
+	}
+
+	var out strings.Builder
+	regressed, err := bench.Report(&out, ds)
+	if err != nil || !regressed {
+		t.Fatalf("expect regression actual %v %v", regressed, err)
+	}
+	expect := `name                     old ns/op  new ns/op  delta    old B/op  new B/op  delta    old allocs/op  new allocs/op  delta
+BenchmarkAdded           -          10000      new      -         0         new      -              0              new
+BenchmarkJSON/n=10       650000     660000     +1.54%   200000    200000    +0.00%   7700           7700           +0.00%
+BenchmarkLisp/n=10       1000000    900000     -10.00%  400000    480000    +20.00%  16000          16000          +0.00%   !
+BenchmarkRemoved         10000      -          removed  0         -         removed  0              -              removed
+BenchmarkState/tok/n=16  10000      10000      +0.00%   0         16        +inf     0              1              +inf     !
+`
+	if out.String() != expect {
+		t.Errorf("expect\n%s\nactual\n%s", expect, out.String())
+	}
+}
@@ -0,0 +1,94 @@
+package example
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/states/bytestate"
+)
+
+func TestBinary(t *testing.T) {
+	// magic | u16le count | align 4 | count * (len-prefixed name, i32be, f64le) | varint
+	var buf bytes.Buffer
+	varint := make([]byte, binary.MaxVarintLen64)
+	buf.WriteString("PSC\x01")
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(2))
+	buf.Write([]byte{0, 0})
+	for i, name := range []string{"a", "你好"} {
+		buf.Write(varint[:binary.PutUvarint(varint, uint64(len(name)))])
+		buf.WriteString(name)
+		_ = binary.Write(&buf, binary.BigEndian, int32(-i-1))
+		_ = binary.Write(&buf, binary.LittleEndian, float64(i)+0.5)
+	}
+	buf.Write(varint[:binary.PutVarint(varint, -300)])
+
+	entry := List(LengthPrefixed(Uvarint, nil).Map(func(v interface{}) interface{} { return string(v.([]byte)) }), I32BE, Float64LE)
+	file := Right(Magic([]byte("PSC\x01")), U16LE.FlatMap(func(n interface{}) Parser {
+		return Right(Align(4), List(Count(entry, int(n.(uint16))), Varint))
+	}))
+
+	s := NewBinaryState(buf.Bytes())
+	v, err := ExpectEof(file).Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	expect := "[[[a -1 0.5] [你好 -2 1.5]] -300]"
+	if fmt.Sprint(v) != expect {
+		t.Errorf("expect %s actual %v", expect, v)
+	}
+	if s.Save().String() != "offset 43 (0x2b)" {
+		t.Errorf("expect offset 43 actual %s", s.Save())
+	}
+
+	for _, tt := range []struct {
+		p     Parser
+		in    []byte
+		error string
+	}{
+		{Magic([]byte("PSC\x01")), []byte("PNG\x01"), "expect magic `50 53 43 01` actual `50 4e 47 01` in offset 0 (0x0)"},
+		{List(U8, U32LE), []byte{1, 2, 3}, "expect `u32le` actual end of input in offset 1 (0x1)"},
+		{List(U8, Char(0)), []byte{1, 0xff}, "expect `\x00` actual 0xff in offset 1 (0x1)"},
+		{Uvarint, []byte{0x80, 0x80}, "expect `uvarint` actual end of input in offset 0 (0x0)"},
+		{LengthPrefixed(U8, List(U8, U8)), []byte{3, 1, 2, 3}, "expect end of 3 bytes block in offset 3 (0x3)"},
+		{LengthPrefixed(U8, List(U8, U8)), []byte{1, 1, 2}, "expect `u8` actual end of input in offset 2 (0x2)"},
+		{LengthPrefixed(I8, nil), []byte{0xff}, "invalid length -1 in offset 0 (0x0)"},
+		{LengthPrefixed(Uvarint, Many(U8)), []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, 'a'},
+			"expect 9223372036854775807 bytes actual end of input in offset 9 (0x9)"},
+		{List(U8, Align(4)), []byte{1, 0}, "expect `align 4` actual end of input in offset 1 (0x1)"},
+	} {
+		t.Run(tt.error, func(t *testing.T) {
+			_, err := tt.p.Parse(NewBinaryState(tt.in))
+			if err == nil || err.Error() != tt.error {
+				t.Errorf("expect \"%s\" actual \"%v\"", tt.error, err)
+			}
+		})
+	}
+}
+
+// LengthPrefixed 窗口中的 Memo 结果不能在窗口外复用
+func TestLengthPrefixedMemo(t *testing.T) {
+	rest := Memo(Many(U8))
+	p := ExpectEof(Alt(
+		Right(LengthPrefixed(U8, rest), Magic([]byte{0xff})),
+		Right(U8, rest),
+	))
+	v, err := p.Parse(NewBinaryState([]byte{2, 1, 2, 3, 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(v) != "[1 2 3 4]" {
+		t.Errorf("expect [1 2 3 4] actual %v", v)
+	}
+}
+
+func TestAlignPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "Align: n must be > 0" {
+			t.Errorf("expect panic actual %v", r)
+		}
+	}()
+	Align(0)
+}
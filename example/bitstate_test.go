@@ -0,0 +1,62 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/states/bitstate"
+)
+
+func TestBitState(t *testing.T) {
+	// ipv4 头部片段: version(4) ihl(4) | dscp(6) ecn(2) | ... | flags(3) fragment offset(13)
+	ipv4 := List(Bits(4), Bits(4), Bits(6), Bits(2), Bits(16), Bits(16), Zero, Flag, Flag, Bits(13))
+	v, err := ExpectEof(ipv4).Parse(NewState([]byte{0x45, 0x00, 0x00, 0x54, 0xab, 0xcd, 0x40, 0x00}))
+	if err != nil {
+		panic(err)
+	}
+	expect := "[4 5 0 0 84 43981 0 true false 0]"
+	if fmt.Sprint(v) != expect {
+		t.Errorf("expect %s actual %v", expect, v)
+	}
+
+	// deflate stored block: bfinal(1) btype(2) 对齐到字节, len(16) nlen(16) data
+	deflate := List(Flag, Bits(2), AlignByte, Bits(16).FlatMap(func(n interface{}) Parser {
+		return Right(Bits(16), Bytes(int(n.(uint64))))
+	}))
+	v, err = ExpectEof(deflate).Parse(NewStateLSB([]byte{0x01, 0x02, 0x00, 0xfd, 0xff, 'h', 'i'}))
+	if err != nil {
+		panic(err)
+	}
+	expect = "[true 0 <nil> [104 105]]"
+	if fmt.Sprint(v) != expect {
+		t.Errorf("expect %s actual %v", expect, v)
+	}
+
+	v, err = Many(AnyBit).Parse(NewStateLSB([]byte{0x06}))
+	if err != nil {
+		panic(err)
+	}
+	if fmt.Sprint(v) != "[0 1 1 0 0 0 0 0]" {
+		t.Errorf("expect [0 1 1 0 0 0 0 0] actual %v", v)
+	}
+
+	for _, tt := range []struct {
+		p     Parser
+		in    []byte
+		error string
+	}{
+		{List(Bits(3), One), []byte{0x00}, "expect `1` actual `0` in bit 3 (byte 0 + 3)"},
+		{List(Bits(8), Bits(8), Bits(1), One), []byte{0xff, 0xff, 0x00}, "expect `1` actual `0` in bit 17 (byte 2 + 1)"},
+		{List(Bits(4), Bits(5)), []byte{0xff}, "expect `5 bits` actual end of input in bit 4 (byte 0 + 4)"},
+		{List(Bits(1), Bytes(1)), []byte{0xff, 0xff}, "expect byte aligned before `1 bytes` in bit 1 (byte 0 + 1)"},
+		{List(Bits(8), AnyBit), []byte{0xff}, "expect `bit` actual end of input in bit 8 (byte 1 + 0)"},
+	} {
+		t.Run(tt.error, func(t *testing.T) {
+			_, err := tt.p.Parse(NewState(tt.in))
+			if err == nil || err.Error() != tt.error {
+				t.Errorf("expect \"%s\" actual \"%v\"", tt.error, err)
+			}
+		})
+	}
+}
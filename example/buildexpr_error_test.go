@@ -0,0 +1,59 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestBuildExpressionParserError(t *testing.T) {
+	tok := func(s string) Parser { return Trim(Str(s), Space) }
+	un := func(op Op, x interface{}) interface{} { return fmt.Sprintf("(%s %s)", op.Value, x) }
+	bin := func(op Op, l, r interface{}) interface{} { return fmt.Sprintf("(%s %s %s)", op.Value, l, r) }
+
+	table := OperatorTable{
+		{PrefixOp(tok("-"), un), PostfixOp(tok("!"), un)},
+		{InfixL(tok("*"), bin)},
+		{InfixL(tok("+"), bin), InfixR(tok("^"), bin)},
+		{InfixN(tok("=="), bin), InfixN(tok("<"), bin)},
+	}
+	Expr := NewRule()
+	// 括号分支放在最后, Alt 不会对最后一个分支 Try, 括号内的错误不会被吞掉
+	term := Label(Alt(Trim(Regex(`\d+`), Space), Mid(tok("("), Expr, tok(")"))), "expect simple expression")
+	Expr.Pattern = BuildExpressionParser(table, term)
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+		error  string
+	}{
+		{s: "1 + 2 * 3 == -4!", expect: "(== (+ 1 (* 2 3)) (! (- 4)))"},
+		{s: "1 + * 2", error: "expect expression after `+` in pos 5 line 1 col 5"},
+		{s: "1 * (2 +)", error: "expect expression after `+` in pos 9 line 1 col 9"},
+		{s: "1 == -", error: "expect expression after `-` in pos 7 line 1 col 7"},
+		{s: "1 == 2 == 3", error: "operator `==` is non-associative, add parentheses in pos 8 line 1 col 8"},
+		{s: "1 < 2 == 3", error: "operator `==` is non-associative, add parentheses in pos 7 line 1 col 7"},
+		{s: "1 + 2 ^ 3", error: "operator `+` is left associative and `^` is right associative, add parentheses in pos 7 line 1 col 7"},
+		{s: "1 ^ 2 + 3", error: "operator `^` is right associative and `+` is left associative, add parentheses in pos 7 line 1 col 7"},
+		{s: "(1 == 2) == 3", expect: "(== (== 1 2) 3)"},
+		{s: "1 + 2 ) ", error: "expect end of input in pos 7 line 1 col 7"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := ExpectEof(Expr).Parse(NewState(tt.s))
+			if err != nil {
+				actual := err.Error()
+				if actual != tt.error {
+					t.Errorf("expect \"%s\" actual \"%s\"", tt.error, actual)
+				}
+				return
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+			}
+		})
+	}
+}
@@ -7,25 +7,10 @@ import (
 	. "github.com/goghcrow/parsec/states/charstate"
 )
 
-// 处理公共前缀 parser 的回溯
-func Cache(p Parser) Parser {
-	return NewParser(func(s State) (interface{}, error) {
-		c := getCache(s)
-		it := c.get(p, s)
-		if it == nil {
-			v, err := p.Parse(s)
-			c.put(p, s, v, err)
-			return v, err
-		} else {
-			s.Restore(it.rest)
-			return it.result, it.err
-		}
-	})
-}
-
+// 处理公共前缀 parser 的回溯, 缓存保存在单次解析的 Context 中
 func TestCommonPrefix(t *testing.T) {
-	a_ := Cache(Str("a"))
-	b_ := Cache(Str("b"))
+	a_ := Memo(Str("a"))
+	b_ := Memo(Str("b"))
 	p := Alt(Rep(a_, 10), Seq(Rep(a_, 9), b_, func(xs, x interface{}) interface{} {
 		return append(xs.([]interface{}), x)
 	}))
@@ -36,8 +21,8 @@ func TestCommonPrefix(t *testing.T) {
 }
 
 func BenchmarkCache(b *testing.B) {
-	a_ := Cache(Str("a"))
-	b_ := Cache(Str("b"))
+	a_ := Memo(Str("a"))
+	b_ := Memo(Str("b"))
 	p := Alt(Rep(a_, 10), Seq(Rep(a_, 9), b_, func(xs, x interface{}) interface{} {
 		return append(xs.([]interface{}), x)
 	}))
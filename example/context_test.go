@@ -0,0 +1,183 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+type ruleTracer struct {
+	depth int
+	log   []string
+}
+
+func (t *ruleTracer) Enter(r *SyntaxRule, pos Pos) {
+	t.log = append(t.log, fmt.Sprintf("%s%s@%d", strings.Repeat(" ", t.depth), r.Name, pos.Idx))
+	t.depth++
+}
+func (t *ruleTracer) Exit(r *SyntaxRule, pos Pos, v interface{}, err error) {
+	t.depth--
+	if err != nil {
+		t.log = append(t.log, fmt.Sprintf("%s%s!", strings.Repeat(" ", t.depth), r.Name))
+	} else {
+		t.log = append(t.log, fmt.Sprintf("%s%s=%v@%d", strings.Repeat(" ", t.depth), r.Name, v, pos.Idx))
+	}
+}
+
+// 全局共享的 parser, 所有可变状态都在 per-parse Context 中
+var sharedCalc = func() Parser {
+	tok := func(s string) Parser { return Trim(Str(s), Space) }
+	num := func(op Op, l, r interface{}) interface{} {
+		x, y := l.(int), r.(int)
+		switch op.Value {
+		case "+":
+			return x + y
+		case "-":
+			return x - y
+		case "*":
+			return x * y
+		default:
+			panic("unreached")
+		}
+	}
+	Expr := NewNamedRule("expr")
+	Num := NewNamedRule("num")
+	Num.Pattern = Memo(Trim(Regex(`\d+`), Space).Map(func(v interface{}) interface{} {
+		n := 0
+		_, _ = fmt.Sscan(v.(string), &n)
+		return n
+	}))
+	term := Alt(Num, Mid(tok("("), Expr, tok(")")))
+	Expr.Pattern = BuildExpressionParser(OperatorTable{
+		{InfixL(tok("*"), num)},
+		{InfixL(tok("+"), num), InfixL(tok("-"), num)},
+	}, term)
+	return ExpectEof(Expr)
+}()
+
+func TestConcurrentParse(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := NewContext(context.Background())
+			c.Tracer = &ruleTracer{}
+			src := fmt.Sprintf("%d * (%d + 1) - 2", i, i)
+			v, err := Run(c, sharedCalc, NewState(src))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if v.(int) != i*(i+1)-2 {
+				t.Errorf("%s expect %d actual %d", src, i*(i+1)-2, v)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Run(NewContext(ctx), sharedCalc, NewState("1 + 2"))
+	if !IsAbort(err) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expect aborted actual %v", err)
+	}
+	expect := "parse aborted: context canceled in pos 1 line 1 col 1"
+	if err.Error() != expect {
+		t.Errorf("expect \"%s\" actual \"%s\"", expect, err)
+	}
+
+	// 中止不会被 Alt 吞掉
+	ctx, cancel = context.WithCancel(context.Background())
+	cancelRule := NewNamedRule("cancel")
+	cancelRule.Pattern = NewParser(func(s State) (interface{}, error) {
+		cancel()
+		return Fail("cancel").Parse(s)
+	})
+	next := NewNamedRule("next")
+	next.Pattern = Str("a")
+	_, err = Run(NewContext(ctx), Alt(cancelRule, next), NewState("a"))
+	if !IsAbort(err) {
+		t.Errorf("expect aborted actual %v", err)
+	}
+}
+
+func TestContextTracer(t *testing.T) {
+	tr := &ruleTracer{}
+	c := NewContext(context.Background())
+	c.Tracer = tr
+	_, err := Run(c, sharedCalc, NewState("1+(2)"))
+	if err != nil {
+		panic(err)
+	}
+	expect := `expr@0
+ num@0
+ num=1@1
+ num@2
+ num!
+ expr@3
+  num@3
+  num=2@4
+ expr=2@4
+expr=3@5`
+	actual := strings.Join(tr.log, "\n")
+	if actual != expect {
+		t.Errorf("expect \n%s\nactual\n%s", expect, actual)
+	}
+}
+
+func TestMemo(t *testing.T) {
+	tr := &ruleTracer{}
+	a := NewNamedRule("a")
+	a.Pattern = Str("a")
+	b := NewNamedRule("b")
+	b.Pattern = Str("b")
+	ma := Memo(a)
+	p := Alt(List(ma, b), List(ma, a))
+
+	c := NewContext(context.Background())
+	c.Tracer = tr
+	v, err := Run(c, p, NewState("aa"))
+	if err != nil {
+		panic(err)
+	}
+	if fmt.Sprint(v) != "[a a]" {
+		t.Errorf("expect [a a] actual %s", v)
+	}
+	// 第二个分支中 Memo(a) 命中缓存, a 只在位置 0 解析一次
+	expect := "a@0 a=a@1 b@1 b! a@1 a=a@2"
+	actual := strings.Join(tr.log, " ")
+	if actual != expect {
+		t.Errorf("expect \"%s\" actual \"%s\"", expect, actual)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	stmt := Recover(Left(Trim(Regex(`\d+`), Space), Str(";")), ManyTill(Any, Str(";")), "bad")
+	c := NewContext(context.Background())
+	v, err := Run(c, ExpectEof(Many(stmt)), NewState("1; x; 2; 3 y;"))
+	if err != nil {
+		panic(err)
+	}
+	if fmt.Sprint(v) != "[1 bad 2 bad]" {
+		t.Errorf("expect [1 bad 2 bad] actual %s", v)
+	}
+	var errs []string
+	for _, e := range c.Errors() {
+		errs = append(errs, e.Error())
+	}
+	expect := "expect pattern '\\d+' in pos 4 line 1 col 4; expect `;` actual `y` in pos 12 line 1 col 12"
+	actual := strings.Join(errs, "; ")
+	if actual != expect {
+		t.Errorf("expect \"%s\" actual \"%s\"", expect, actual)
+	}
+}
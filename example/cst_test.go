@@ -0,0 +1,176 @@
+package example
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goghcrow/lexer"
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/cst"
+	. "github.com/goghcrow/parsec/states/charstate"
+	"github.com/goghcrow/parsec/states/tokstate"
+)
+
+func TestCST(t *testing.T) {
+	Expr := NewNamedRule("expr")
+	Num := NewNamedRule("num")
+	Paren := NewNamedRule("paren")
+	// 未命名的 rule 不产生节点
+	Term := NewRule()
+
+	tok := func(r rune) Parser { return Trim(Char(r), Space) }
+	Num.Pattern = Trim(Regex(`\d+`), Space).Map(func(v interface{}) interface{} { n, _ := strconv.Atoi(v.(string)); return n })
+	Paren.Pattern = Mid(tok('('), Expr, tok(')'))
+	// 第一个分支在 num 之后回溯, 不会留下重复的 num 节点
+	Term.Pattern = Alt(Left(Num, tok('!')), Num, Paren)
+	Expr.Pattern = Chainl1(Term, tok('+').Map(func(interface{}) interface{} {
+		return func(x, y interface{}) interface{} { return x.(int) + y.(int) }
+	}))
+
+	src := " 1 + ( 2+3 ) "
+	v, root, err := cst.Parse(ExpectEof(Expr), NewState(src))
+	if err != nil {
+		panic(err)
+	}
+	if v != 6 {
+		t.Errorf("expect 6 actual %v", v)
+	}
+	if root.String() != src {
+		t.Errorf("expect %q actual %q", src, root.String())
+	}
+	expect := `#root 0-13
+  expr 0-13
+    num 0-3
+      #trivia " "
+      #token "1"
+      #trivia " "
+    #token "+"
+    #trivia " "
+    paren 5-13
+      #token "("
+      #trivia " "
+      expr 7-11
+        num 7-8
+          #token "2"
+        #token "+"
+        num 9-11
+          #token "3"
+          #trivia " "
+      #token ")"
+      #trivia " "
+`
+	if root.Dump() != expect {
+		t.Errorf("expect \n%s\nactual\n%s", expect, root.Dump())
+	}
+	if n := root.Find("paren"); n.Parent.Kind != "expr" || n.String() != "( 2+3 ) " {
+		t.Errorf("unexpected paren node %q", n)
+	}
+	// 每个叶子有各自的范围, 依次首尾相接
+	at := Pos{}
+	for _, n := range cstLeaves(root) {
+		if n.Start != at || n.End.Idx != n.Start.Idx+len(n.Text) || n.Text != src[n.Start.Idx:n.End.Idx] {
+			t.Errorf("unexpected span %s-%s of %q", n.Start, n.End, n.Text)
+		}
+		at = n.End
+	}
+	if at != root.End {
+		t.Errorf("expect leaves end in %s actual %s", root.End, at)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, root, err := cst.Parse(ExpectEof(Expr), NewState("1 + ("))
+		if err == nil {
+			t.Fatal("expect error")
+		}
+		if root.String() != "1 + (" || len(root.Children) != 5 || !root.Children[0].IsLeaf() {
+			t.Errorf("unexpected cst\n%s", root.Dump())
+		}
+	})
+
+	t.Run("backtracked branch", func(t *testing.T) {
+		// 第一个分支的 num 成功之后失败, 胜出的分支不是命名规则, 结束位置在 num 之后
+		p := ExpectEof(Alt(List(Num, Char('!')), Regex(`\d+\?`)))
+		_, root, err := cst.Parse(p, NewState("12?"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root.Find("num") != nil || root.String() != "12?" {
+			t.Errorf("unexpected cst\n%s", root.Dump())
+		}
+	})
+
+	t.Run("token state with trivia", func(t *testing.T) {
+		const (
+			Ident lexer.TokenKind = iota + 1
+			Eq
+			Comment
+			Space
+		)
+		lex := lexer.BuildLexer(func(lex *lexer.Lexicon) {
+			lex.Str(Eq, "=")
+			lex.Regex(Ident, `[a-z]+`)
+			lex.Regex(Comment, `#[^\n]*`)
+			lex.Regex(Space, `\s+`)
+		})
+		isTrivia := func(tok *lexer.Token) bool { return tok.TokenKind == Comment || tok.TokenKind == Space }
+
+		Assign := NewNamedRule("assign")
+		Assign.Pattern = List(tokstate.Tok(Ident, "ident"), tokstate.Str("="), tokstate.Tok(Ident, "ident"))
+		src := "# config\na = b # first\n  c=d\n# end\n"
+		_, root, err := cst.Parse(ExpectEof(Many(Assign)), tokstate.NewStateWithTrivia(lex.MustLex(src), isTrivia))
+		if err != nil {
+			panic(err)
+		}
+		if root.String() != src {
+			t.Errorf("expect %q actual %q", src, root.String())
+		}
+		// 换行之后的缩进与换行在同一个空白 token 中, 属于前一个 token 的 trailing trivia
+		var assigns []string
+		for _, n := range root.Children {
+			if n.Kind == "assign" {
+				assigns = append(assigns, n.String())
+			}
+		}
+		actual := strings.Join(assigns, "|")
+		if actual != "# config\na = b # first\n  |c=d\n" {
+			t.Errorf("unexpected assigns %q", actual)
+		}
+		// 每个 token 与 trivia 各为一个叶子
+		expect := `assign 0-3
+  #trivia "# config"
+  #trivia "\n"
+  #token "a"
+  #trivia " "
+  #token "="
+  #trivia " "
+  #token "b"
+  #trivia " "
+  #trivia "# first"
+  #trivia "\n  "
+`
+		if dump := root.Find("assign").Dump(); dump != expect {
+			t.Errorf("expect \n%s\nactual\n%s", expect, dump)
+		}
+		// token 叶子依次占一个位置, trivia 不占位置
+		var spans []string
+		for _, n := range cstLeaves(root.Find("assign")) {
+			spans = append(spans, fmt.Sprintf("%d-%d", n.Start.Idx, n.End.Idx))
+		}
+		if actual := strings.Join(spans, " "); actual != "0-0 0-0 0-1 1-1 1-2 2-2 2-3 3-3 3-3 3-3" {
+			t.Errorf("unexpected spans %s", actual)
+		}
+	})
+}
+
+func cstLeaves(n *cst.Node) []*cst.Node {
+	if n.IsLeaf() {
+		return []*cst.Node{n}
+	}
+	var xs []*cst.Node
+	for _, c := range n.Children {
+		xs = append(xs, cstLeaves(c)...)
+	}
+	return xs
+}
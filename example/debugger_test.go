@@ -0,0 +1,83 @@
+package example
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/debugger"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestDebugger(t *testing.T) {
+	ps := peg.MustLoad(`
+S <- A "+" A / A
+A <- [0-9]
+`).MustBuild(peg.Options{})
+
+	for _, tt := range []struct {
+		name   string
+		cmds   string
+		input  string
+		expect string
+	}{
+		{
+			name:  "step",
+			cmds:  "s\ns\n\nbt\np\ns\nh\nc\n",
+			input: "1",
+			expect: "type `help` for commands\n" +
+				"enter S at pos 1 line 1 col 1\n" +
+				"(parsec) enter A at pos 1 line 1 col 1\n" +
+				"(parsec) exit A at pos 2 line 1 col 2: 1\n" +
+				"(parsec) backtrack S: pos 2 line 1 col 2 -> pos 1 line 1 col 1: expect `+` actual end of input in pos 2 line 1 col 2\n" +
+				"enter A at pos 1 line 1 col 1\n" +
+				"(parsec) #0 A at pos 1 line 1 col 1\n" +
+				"#1 S at pos 1 line 1 col 1\n" +
+				"(parsec) pos 1 line 1 col 1\n" +
+				"rest \"1\"\n" +
+				"(parsec) exit A at pos 2 line 1 col 2: 1\n" +
+				"(parsec) S: pos 2 line 1 col 2 -> pos 1 line 1 col 1: expect `+` actual end of input in pos 2 line 1 col 2\n" +
+				"(parsec) result: 1 at pos 2 line 1 col 2\n",
+		},
+		{
+			name:  "breakpoints & next",
+			cmds:  "b @3\nb A\nd 2\nb\nc\nn\nq\n",
+			input: "1+2",
+			expect: "type `help` for commands\n" +
+				"enter S at pos 1 line 1 col 1\n" +
+				"(parsec) breakpoint 1 @3\n" +
+				"(parsec) breakpoint 2 A\n" +
+				"(parsec) (parsec) 1 @3\n" +
+				"(parsec) breakpoint @3\n" +
+				"enter A at pos 3 line 1 col 3\n" +
+				"(parsec) exit A at pos 4 line 1 col 4: 2\n" +
+				"(parsec) result: [1 + 2] at pos 4 line 1 col 4\n",
+		},
+		{
+			name:   "quit",
+			cmds:   "q\n",
+			input:  "1",
+			expect: "type `help` for commands\nenter S at pos 1 line 1 col 1\n(parsec) error: parse aborted: context canceled in pos 1 line 1 col 1\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			_, _ = debugger.New(strings.NewReader(tt.cmds), &out).Run(ps.Start, charstate.NewState(tt.input))
+			if out.String() != tt.expect {
+				t.Errorf("expect\n%s\nactual\n%s", tt.expect, out.String())
+			}
+		})
+	}
+
+	t.Run("registered grammar", func(t *testing.T) {
+		debugger.Register("digits", debugger.Grammar{Parser: Many1(charstate.Digit), NewState: charstate.NewState})
+		var out strings.Builder
+		if rc := debugger.Main([]string{"-grammar", "digits", "-e", "12"}, strings.NewReader(""), &out); rc != 0 {
+			t.Errorf("expect 0 actual %d: %s", rc, out.String())
+		}
+		if !strings.HasSuffix(out.String(), "result: [49 50] at pos 3 line 1 col 3\n") {
+			t.Errorf("unexpected %s", out.String())
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package example
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+// 源码中声明操作符, 影响之后的表达式
+//
+//	stmt  = decl | expr ";"
+//	decl  = ("infixl" | "infixr" | "infix") prec oper ";"
+func TestDynamicOperatorTable(t *testing.T) {
+	tok := func(p Parser) Parser { return Trim(p, Space) }
+	binary := func(sym string) Parser {
+		return tok(Str(sym)).Map(func(op interface{}) interface{} {
+			return func(l, r interface{}) interface{} { return fmt.Sprintf("(%s %s %s)", op, l, r) }
+		})
+	}
+
+	registry := func(s State) *OperatorRegistry { return s.Get().(*OperatorRegistry) }
+
+	Expr := NewRule()
+	term := Alt(Mid(tok(Char('(')), Expr, tok(Char(')'))), tok(Regex(`\d+`)))
+	Expr.Pattern = BuildDynamicExpressionParser(registry, term)
+
+	fixity := tok(Alt(Str("infixl"), Str("infixr"), Str("infix")))
+	decl := List(fixity, tok(Regex(`\d+`)), tok(Regex(`[-+*/<>^|&=!]+`)), tok(Char(';'))).FlatMap(func(v interface{}) Parser {
+		xs := v.([]interface{})
+		prec, _ := strconv.ParseFloat(xs[1].(string), 32)
+		op := map[string]func(Parser) Operator{
+			"infixl": NewInfixL,
+			"infixr": NewInfixR,
+			"infix":  NewInfixN,
+		}[xs[0].(string)](binary(xs[2].(string)))
+		op.Prec = float32(prec)
+		return NewParser(func(s State) (interface{}, error) {
+			registry(s).Add(op)
+			return nil, nil
+		})
+	})
+	stmt := Alt(decl, Left(Expr, tok(Char(';'))))
+	pgrm := ManyTill(stmt, Eof).Map(func(v interface{}) interface{} {
+		var xs []interface{}
+		for _, x := range v.([]interface{}) {
+			if x != nil {
+				xs = append(xs, x)
+			}
+		}
+		return xs
+	})
+
+	newState := func(s string) State {
+		st := NewState(s)
+		st.Put(NewOperatorRegistry(
+			Operator{OperKind: Infix, Assoc: AssocLeft, Parser: binary("+"), Prec: 6},
+			Operator{OperKind: Infix, Assoc: AssocLeft, Parser: binary("*"), Prec: 7},
+		))
+		return st
+	}
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+		error  string
+		zero   bool // 使用零值 OperatorRegistry
+	}{
+		{s: "1 + 2 * 3;", expect: "[(+ 1 (* 2 3))]"},
+		{s: "1; infixl 6 +; 1 + 2;", expect: "[1 (+ 1 2)]", zero: true},
+		{
+			s:      "1 + 2; infixr 8 ^; 1 + 2 ^ 3 ^ 4 * 5; infix 5 ==; 1 == 2 + 3;",
+			expect: "[(+ 1 2) (+ 1 (* (^ 2 (^ 3 4)) 5)) (== 1 (+ 2 3))]",
+		},
+		{s: "1 ^ 2; infixr 8 ^;", error: "expect `;` actual `^` in pos 3 line 1 col 3"},
+		{s: "infix 5 ==; 1 == 2 == 3;", error: "operator `==` is non-associative, add parentheses in pos 20 line 1 col 20"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			st := newState(tt.s)
+			if tt.zero {
+				st.Put(&OperatorRegistry{})
+			}
+			v, err := pgrm.Parse(st)
+			if err != nil {
+				actual := err.Error()
+				if actual != tt.error {
+					t.Errorf("expect \"%s\" actual \"%s\"", tt.error, actual)
+				}
+				return
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+			}
+		})
+	}
+}
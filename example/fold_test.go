@@ -0,0 +1,119 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+	"unicode"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/states/bytestate"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestFold(t *testing.T) {
+	charIdent := List(charstate.IdentStart, Many(charstate.IdentContinue))
+
+	for _, tt := range []struct {
+		name   string
+		p      Parser
+		s      State
+		expect string
+		error  string
+	}{
+		{
+			name:   "char keyword",
+			p:      List(charstate.StrFold("select"), charstate.Space, charstate.StrFold("from")),
+			s:      charstate.NewState("SeLeCt FROM"),
+			expect: "[SeLeCt 32 FROM]",
+		},
+		{
+			name:   "char simple folding",
+			p:      List(charstate.StrFold("straße"), charstate.CharFold('k')),
+			s:      charstate.NewState("STRA\u1E9EE\u212A"),
+			expect: "[STRA\u1E9EE 8490]",
+		},
+		{
+			name:  "char fold mismatch",
+			p:     charstate.StrFold("select"),
+			s:     charstate.NewState("SELx"),
+			error: "expect `e` actual `x` in pos 4 line 1 col 4",
+		},
+		{
+			name:   "char one of fold",
+			p:      Many1(charstate.OneOfFold("xyz")),
+			s:      charstate.NewState("xYZa"),
+			expect: "[120 89 90]",
+		},
+		{
+			name:   "char range",
+			p:      Many1(charstate.InRange('a', 'f')),
+			s:      charstate.NewState("cafeg"),
+			expect: "[99 97 102 101]",
+		},
+		{
+			name:  "char range mismatch",
+			p:     charstate.InRange('a', 'f'),
+			s:     charstate.NewState("g"),
+			error: "expect `a-f` actual `g` in pos 1 line 1 col 1",
+		},
+		{
+			name:  "char table",
+			p:     List(charstate.InTable(unicode.Han), charstate.InTable(unicode.Han)),
+			s:     charstate.NewState("你a"),
+			error: "expect `Han` actual `a` in pos 2 line 1 col 2",
+		},
+		{
+			name:   "char ident",
+			p:      charIdent.Map(func(v interface{}) interface{} { return fmt.Sprintf("%c", v.([]interface{})[0]) }),
+			s:      charstate.NewState("变量1"),
+			expect: "变",
+		},
+		{
+			name:  "char ident start",
+			p:     charIdent,
+			s:     charstate.NewState("1a"),
+			error: "expect `ident start` actual `1` in pos 1 line 1 col 1",
+		},
+		{
+			name:   "byte keyword",
+			p:      List(bytestate.StrFold("select"), bytestate.Space, bytestate.StrFold("FROM")),
+			s:      bytestate.NewState("SELECT from"),
+			expect: "[SELECT 32 from]",
+		},
+		{
+			name:  "byte fold ascii only",
+			p:     bytestate.StrFold("k"),
+			s:     bytestate.NewState("\u212A"),
+			error: "expect `k` actual `\u00e2` in pos 1 line 1 col 1",
+		},
+		{
+			name:   "byte one of fold & range",
+			p:      List(bytestate.OneOfFold("xy"), bytestate.CharFold('z'), bytestate.InRange('0', '9')),
+			s:      bytestate.NewState("YZ7"),
+			expect: "[89 90 55]",
+		},
+		{
+			name:   "byte ident",
+			p:      List(bytestate.IdentStart, Many(bytestate.IdentContinue)),
+			s:      bytestate.NewState("_a1-"),
+			expect: "[95 [97 49]]",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.p.Parse(tt.s)
+			if tt.error != "" {
+				if err == nil || err.Error() != tt.error {
+					t.Errorf("expect \"%s\" actual \"%v\"", tt.error, err)
+				}
+				return
+			}
+			if err != nil {
+				panic(err)
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect %q actual %q", tt.expect, actual)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package example
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/states/bytestate"
 	. "github.com/goghcrow/parsec/states/charstate"
 )
 
@@ -27,3 +29,54 @@ func TestKeyword(t *testing.T) {
 		t.Errorf("expect error %s actual %s", expect, actual)
 	}
 }
+
+func TestSymbols(t *testing.T) {
+	ops := Symbols([]string{"<", "<=", "<<", "<<=", "=", "=="})
+	kws := Keywords([]string{"in", "int", "interface", "if"}, nil)
+	for _, tt := range []struct {
+		p      Parser
+		s      string
+		expect string
+		error  string
+	}{
+		{p: ops, s: "<<=1", expect: "<<="},
+		{p: ops, s: "<<1", expect: "<<"},
+		{p: ops, s: "<=<", expect: "<="},
+		{p: ops, s: "==", expect: "=="},
+		{p: ops, s: "!=", error: "expect `one of < <= << <<= = ==` actual `!` in pos 1 line 1 col 1"},
+		{p: kws, s: "int x", expect: "int"},
+		{p: kws, s: "in(x)", expect: "in"},
+		{p: kws, s: "interface", expect: "interface"},
+		{p: kws, s: "into", error: "expect `one of in int interface if` actual `i` in pos 1 line 1 col 1"},
+		{p: kws, s: "if_", error: "expect `one of in int interface if` actual `i` in pos 1 line 1 col 1"},
+		{p: Keywords([]string{"if"}, func(r rune) bool { return r != '_' && IsIdentContinue(r) }), s: "if_", expect: "if"},
+		{p: kws, s: "", error: "expect `one of in int interface if` actual end of input in pos 1 line 1 col 1"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := tt.p.Parse(NewState(tt.s))
+			if tt.error != "" {
+				if err == nil || err.Error() != tt.error {
+					t.Errorf("expect \"%s\" actual \"%v\"", tt.error, err)
+				}
+				return
+			}
+			if err != nil {
+				panic(err)
+			}
+			if v != tt.expect {
+				t.Errorf("expect %s actual %s", tt.expect, v)
+			}
+		})
+	}
+
+	t.Run("byte", func(t *testing.T) {
+		p := List(bytestate.Keywords([]string{"select", "set"}, nil), bytestate.Space, bytestate.Symbols([]string{">", ">="}))
+		v, err := p.Parse(bytestate.NewState("set >=1"))
+		if err != nil {
+			panic(err)
+		}
+		if fmt.Sprint(v) != "[set 32 >=]" {
+			t.Errorf("expect [set 32 >=] actual %s", v)
+		}
+	})
+}
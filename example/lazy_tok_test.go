@@ -0,0 +1,133 @@
+package example
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/goghcrow/lexer"
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/states/tokstate"
+)
+
+// 模板字符串: "a${x + 1}b", 引号内外使用不同的词法模式
+func TestLazyTokState(t *testing.T) {
+	const (
+		Default Mode = iota
+		Template
+	)
+	const (
+		Int lexer.TokenKind = iota + 1
+		Ident
+		Plus
+		Quote
+		RBrace
+		Chunk
+		Interp
+	)
+	lex := NewRegexLexer().
+		Skip(Default, `\s+`).
+		Regex(Default, Int, `\d+`).
+		Regex(Default, Ident, `[a-z]+`).
+		Str(Default, Plus, "+").
+		Str(Default, Quote, `"`).
+		Str(Default, RBrace, "}").
+		Str(Template, Interp, "${").
+		Str(Template, Quote, `"`).
+		Regex(Template, Chunk, `(?:[^"$]|\$[^{])+`)
+
+	lexeme := func(v interface{}) interface{} { return v.(*lexer.Token).Lexeme }
+	env := map[string]interface{}{"x": 1}
+	add := func(x, y interface{}) interface{} {
+		a, ok1 := x.(int)
+		b, ok2 := y.(int)
+		if ok1 && ok2 {
+			return a + b
+		}
+		return fmt.Sprint(x) + fmt.Sprint(y)
+	}
+
+	Expr := NewRule()
+	tmpl := Mid(Tok(Quote, `"`), WithMode(Template, Many(Alt(
+		Tok(Chunk, "chunk").Map(lexeme),
+		Right(Tok(Interp, "${"), WithMode(Default, Left(Expr, Tok(RBrace, "}")))),
+	))), Tok(Quote, `"`)).Map(func(v interface{}) interface{} {
+		s := ""
+		for _, x := range v.([]interface{}) {
+			s += fmt.Sprint(x)
+		}
+		return s
+	})
+	atom := Alt(
+		Tok(Int, "int").Map(func(v interface{}) interface{} { n, _ := strconv.Atoi(lexeme(v).(string)); return n }),
+		Tok(Ident, "ident").Map(func(v interface{}) interface{} { return env[lexeme(v).(string)] }),
+		tmpl,
+	)
+	Expr.Pattern = Chainl1(atom, Tok(Plus, "+").Map(func(interface{}) interface{} { return add }))
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+	}{
+		{`1 + x`, "2"},
+		{`"a${x + 1}b"`, "a2b"},
+		{`"a $ b" + 1`, "a $ b1"},
+		{`"a${x + 1}b${"c${x}"}" + 2`, "a2bc12"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := ExpectEof(Expr).Parse(NewLazyState(tt.s, lex))
+			if err != nil {
+				panic(err)
+			}
+			if fmt.Sprint(v) != tt.expect {
+				t.Errorf("expect %s actual %v", tt.expect, v)
+			}
+		})
+	}
+
+	t.Run("lazy", func(t *testing.T) {
+		calls := 0
+		counting := LexerFunc(func(src string, at Pos, mode Mode) (*lexer.Token, Pos, error) {
+			calls++
+			return lex.Lex(src, at, mode)
+		})
+
+		// 回溯时复用缓存的 token
+		p := Alt(List(Tok(Int, "int"), Tok(Ident, "ident")), List(Tok(Int, "int"), Tok(Int, "int")))
+		if _, err := p.Parse(NewLazyState("1 2", counting)); err != nil {
+			panic(err)
+		}
+		if calls != 2 {
+			t.Errorf("expect 2 lex calls actual %d", calls)
+		}
+
+		// 在第一行失败时, 之后的输入不会被切分
+		calls = 0
+		s := NewLazyState("1 + +\n@@@", counting)
+		_, err := ExpectEof(Expr).Parse(s)
+		if err == nil {
+			t.Fatal("expect error")
+		}
+		if calls != 3 || s.(*LazyState).Err() != nil {
+			t.Errorf("expect 3 lex calls without lex error actual %d %v", calls, s.(*LazyState).Err())
+		}
+
+		// 词法错误中止解析, 不会被当作输入结束
+		for _, tt := range []struct {
+			s, error string
+		}{
+			{"1 +\n@", "parse aborted: unexpected `@` in mode 0 in pos 5 line 2 col 1"},
+			{"1 + 2 @@ garbage", "parse aborted: unexpected `@` in mode 0 in pos 7 line 1 col 7"},
+			{`"a${x @}"`, "parse aborted: unexpected `@` in mode 0 in pos 7 line 1 col 7"},
+		} {
+			s := NewLazyState(tt.s, lex)
+			v, err := ExpectEof(Expr).Parse(s)
+			if err == nil || err.Error() != tt.error {
+				t.Errorf("%s: expect \"%s\" actual %v \"%v\"", tt.s, tt.error, v, err)
+			}
+			if s.(*LazyState).Err() != err {
+				t.Errorf("%s: expect Err() %v actual %v", tt.s, err, s.(*LazyState).Err())
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestLimits(t *testing.T) {
+	// 嵌套括号
+	Paren := NewNamedRule("paren")
+	Paren.Pattern = Alt(Mid(Char('('), Paren, Char(')')), Char('1'))
+
+	// 指数级回溯: 每个 a 都会尝试两个分支
+	Exp := NewNamedRule("exp")
+	Exp.Pattern = Alt(
+		List(Char('a'), Exp, Char('x')),
+		List(Char('a'), Exp, Char('y')),
+		Nil,
+	)
+
+	// 10 层操作符, 每个操作符之后都会尝试并失败其他层, 但不消费 state
+	ops := "^*/%+-<>&|"
+	table := make(exprparser.OperatorTable, len(ops))
+	for i, op := range ops {
+		build := func(op exprparser.Op, l, r interface{}) interface{} { return nil }
+		table[i] = []exprparser.Operator{exprparser.InfixL(Left(Char(op), Spaces), build)}
+	}
+	Expr := exprparser.BuildExpressionParser(table, Left(Char('1'), Spaces))
+
+	for _, tt := range []struct {
+		name   string
+		p      Parser
+		s      string
+		limits Limits
+		error  string
+	}{
+		{
+			name:   "depth",
+			p:      Paren,
+			s:      strings.Repeat("(", 10) + "1" + strings.Repeat(")", 10),
+			limits: Limits{MaxDepth: 5},
+			error:  "parse aborted: exceed max depth 5 in rule paren in pos 6 line 1 col 6",
+		},
+		{
+			name:   "depth ok",
+			p:      Paren,
+			s:      "((1))",
+			limits: Limits{MaxDepth: 5},
+		},
+		{
+			name:   "steps",
+			p:      Many(Char('a')),
+			s:      strings.Repeat("a", 100),
+			limits: Limits{MaxSteps: 50},
+			error:  "parse aborted: exceed max steps 50 in pos 11 line 1 col 11",
+		},
+		{
+			name:   "backtracks",
+			p:      Exp,
+			s:      strings.Repeat("a", 30) + "z",
+			limits: Limits{MaxBacktracks: 100},
+			error:  "parse aborted: exceed max backtracks 100 in rule exp in pos 28 line 1 col 28",
+		},
+		{
+			name:   "failed alternatives are not backtracks",
+			p:      ExpectEof(Many(Alt(Char('x'), Char('y'), Char('z'), Char('a')))),
+			s:      strings.Repeat("a", 100),
+			limits: Limits{MaxBacktracks: 1},
+		},
+		{
+			name:   "operator probes are not backtracks",
+			p:      ExpectEof(Expr),
+			s:      "1 | 1 ^ 1 + 1 & 1",
+			limits: Limits{MaxBacktracks: 1},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewContext(context.Background())
+			c.Limits = tt.limits
+			_, err := Run(c, tt.p, NewState(tt.s))
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !IsAbort(err) || !errors.As(err, &limitErr) {
+				t.Fatalf("expect limit error actual %v", err)
+			}
+			if err.Error() != tt.error {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.error, err)
+			}
+		})
+	}
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := Run(NewContext(ctx), Exp, NewState(strings.Repeat("a", 64)+"z"))
+		if !IsAbort(err) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expect deadline exceeded actual %v", err)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("expect abort in time actual %s", d)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package lisp
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// pgrm sExprParser 都是包级别共享的 parser, go test -race 验证并发安全
+func TestConcurrentParse(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := fmt.Sprintf("(define x %d) (display '(x . \"%d\"))", i, i)
+			expect := fmt.Sprintf(`[(define x %d) (display (quote (x . "%d")))]`, i, i)
+			for _, parse := range []func(string) (interface{}, error){parse, sExprParser} {
+				v, err := parse(src)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if fmt.Sprint(v) != expect {
+					t.Errorf("expect %s actual %s", expect, v)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,22 @@
+package lisp
+
+import (
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/parsectest"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+// go test ./example/lisp -run TestGolden -update
+func TestGolden(t *testing.T) {
+	parsectest.Golden(t, "testdata", "*.lisp", parse)
+}
+
+func TestAssert(t *testing.T) {
+	v := parsectest.AssertParses(t, pgrm, charstate.NewState("(a . b)"))
+	if s := v.([]interface{})[0].(*pair).String(); s != "(a . b)" {
+		t.Errorf("expect (a . b) actual %s", s)
+	}
+	parsectest.AssertFailsAt(t, pgrm, charstate.NewState("(a)\n )"), Pos{Idx: 5, Line: 1, Col: 1}, "expect end of input")
+}
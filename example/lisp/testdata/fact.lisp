@@ -0,0 +1,9 @@
+
+(define (fact n) 
+	(if (= n 0)
+		1
+		( * n (fact(- n 1))))) ; fact
+(fact 10)
+; hello world
+(display '('"hello\n" . '"world\t!"))
+; comment eof
@@ -0,0 +1,2 @@
+(list 1 2.5 "三" (quote x))
+(a . (b . (c . ())))
@@ -0,0 +1,60 @@
+package example
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/goghcrow/parsec/parsectest"
+)
+
+func TestParsectestUpdating(t *testing.T) {
+	// -update 由 parsectest 注册
+	f := flag.Lookup("update")
+	if f == nil {
+		t.Fatal("expect -update flag")
+	}
+	defer func(v string) { _ = f.Value.Set(v) }(f.Value.String())
+	setUpdate := func(v bool) {
+		if err := f.Value.Set(strconv.FormatBool(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		name   string
+		env    string
+		flag   bool
+		expect bool
+	}{
+		{"default", "", false, false},
+		{"env", "1", false, true},
+		{"env true", "true", false, true},
+		{"env false", "0", false, false},
+		{"env invalid", "x", false, false},
+		{"flag", "", true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(parsectest.UpdateEnv, tt.env)
+			setUpdate(tt.flag)
+			if actual := parsectest.Updating(); actual != tt.expect {
+				t.Errorf("expect %v actual %v", tt.expect, actual)
+			}
+		})
+	}
+
+	t.Run("rewrite", func(t *testing.T) {
+		setUpdate(false)
+		golden := filepath.Join(t.TempDir(), "x.golden")
+		t.Setenv(parsectest.UpdateEnv, "1")
+		parsectest.AssertGolden(t, golden, "ok\n1\n")
+		bs, err := ioutil.ReadFile(golden)
+		if err != nil || string(bs) != "ok\n1\n" {
+			t.Fatalf("unexpected %q %v", bs, err)
+		}
+		t.Setenv(parsectest.UpdateEnv, "")
+		parsectest.AssertGolden(t, golden, "ok\n1\n")
+	})
+}
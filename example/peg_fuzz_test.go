@@ -0,0 +1,23 @@
+//go:build go1.18
+// +build go1.18
+
+package example
+
+import (
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/example/peggen/calc"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+// go test ./example -run '^$' -fuzz FuzzPEG
+func FuzzPEG(f *testing.F) {
+	g := peg.MustLoad(calc.Grammar)
+	ps := g.MustBuild(peg.Options{Skip: charstate.Spaces})
+	g.MustSampler(peg.SampleOptions{MaxDepth: 6, Sep: " "}).Fuzz(f, 1, 50, func(s string) error {
+		_, err := ExpectEof(ps.Start).Parse(charstate.NewState(s))
+		return err
+	})
+}
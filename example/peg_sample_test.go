@@ -0,0 +1,77 @@
+package example
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/example/peggen/calc"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestSample(t *testing.T) {
+	t.Run("sentences parse", func(t *testing.T) {
+		g := peg.MustLoad(calc.Grammar)
+		ps := g.MustBuild(peg.Options{Skip: charstate.Spaces})
+		sampler := g.MustSampler(peg.SampleOptions{MaxDepth: 6, Sep: " "})
+		err := sampler.Check(rand.New(rand.NewSource(1)), 200, func(s string) error {
+			_, err := ExpectEof(ps.Start).Parse(charstate.NewState(s))
+			return err
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("weights & depth", func(t *testing.T) {
+		g := peg.MustLoad(`S <- "(" S ")" / "x"`)
+		sampler := g.MustSampler(peg.SampleOptions{MaxDepth: 3, Weights: map[string][]int{"S": {1, 0}}})
+		if s := sampler.Sample(rand.New(rand.NewSource(1))); s != "(((x)))" {
+			t.Errorf("expect (((x))) actual %s", s)
+		}
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		g := peg.MustLoad("S <- `[a-c]{2}(?i:x)+` [0-9]")
+		re := regexp.MustCompile(`^[a-c]{2}[xX]+[0-9]$`)
+		sampler := g.MustSampler(peg.SampleOptions{})
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 50; i++ {
+			if s := sampler.Sample(rnd); !re.MatchString(s) {
+				t.Errorf("unexpected %q", s)
+			}
+		}
+	})
+
+	t.Run("terminals", func(t *testing.T) {
+		g := peg.MustLoad(`S <- Ident "=" Ident`)
+		sampler := g.MustSampler(peg.SampleOptions{
+			Sep:       " ",
+			Terminals: map[string]func(*rand.Rand) string{"Ident": func(*rand.Rand) string { return "a" }},
+		})
+		if s := sampler.Sample(rand.New(rand.NewSource(1))); s != "a = a " {
+			t.Errorf("expect %q actual %q", "a = a ", s)
+		}
+	})
+
+	for _, tt := range []struct {
+		grammar string
+		opts    peg.SampleOptions
+		error   string
+	}{
+		{`A <- "a" A`, peg.SampleOptions{}, "rule `A` never terminates"},
+		{`A <- B`, peg.SampleOptions{}, "rule `A`: undefined `B`"},
+		{"A <- `(`", peg.SampleOptions{}, "rule `A`: error parsing regexp: missing closing ): `(`"},
+		{`A <- "a" / "b"`, peg.SampleOptions{Weights: map[string][]int{"A": {1}}}, "rule `A`: expect 2 weights"},
+		{`A <- "a"`, peg.SampleOptions{Weights: map[string][]int{"B": {1}}}, "weights for undefined rule `B`"},
+	} {
+		t.Run(tt.error, func(t *testing.T) {
+			_, err := peg.MustLoad(tt.grammar).Sampler(tt.opts)
+			if err == nil || err.Error() != tt.error {
+				t.Errorf("expect \"%s\" actual \"%v\"", tt.error, err)
+			}
+		})
+	}
+}
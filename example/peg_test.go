@@ -0,0 +1,168 @@
+package example
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+const calcGrammar = `
+# 四则运算
+Expr   <- Term (AddOp Term)*
+Term   <- Factor (MulOp Factor)*
+Factor <- Number / "(" Expr ")" / "-" Factor
+AddOp  <- "+" / "-"
+MulOp  <- "*" / "/"
+Number <- [0-9]+ ("." [0-9]+)?
+Kw     = "select"i !` + "`\\w`" + ` | &"x" .
+`
+
+func TestPEG(t *testing.T) {
+	g := peg.MustLoad(calcGrammar)
+
+	// 规范化输出可以重新加载
+	expect := `Expr <- Term (AddOp Term)*
+Term <- Factor (MulOp Factor)*
+Factor <- Number / "(" Expr ")" / "-" Factor
+AddOp <- "+" / "-"
+MulOp <- "*" / "/"
+Number <- [0-9]+ ("." [0-9]+)?
+Kw <- "select"i !` + "`\\w`" + ` / &"x" .
+`
+	if g.String() != expect {
+		t.Errorf("expect \n%s\nactual\n%s", expect, g)
+	}
+	if peg.MustLoad(g.String()).String() != expect {
+		t.Errorf("round trip failed")
+	}
+
+	fold := func(v interface{}) interface{} {
+		xs := v.([]interface{})
+		acc := xs[0].(float64)
+		for _, x := range xs[1].([]interface{}) {
+			opnd := x.([]interface{})
+			y := opnd[1].(float64)
+			switch opnd[0] {
+			case "+":
+				acc += y
+			case "-":
+				acc -= y
+			case "*":
+				acc *= y
+			case "/":
+				acc /= y
+			}
+		}
+		return acc
+	}
+	ps := g.MustBuild(peg.Options{
+		Skip: charstate.Spaces,
+		Actions: map[string]func(v interface{}) interface{}{
+			"Expr": fold,
+			"Term": fold,
+			"Factor": func(v interface{}) interface{} {
+				switch x := v.(type) {
+				case float64:
+					return x
+				case []interface{}:
+					if x[0] == "-" {
+						return -x[1].(float64)
+					}
+					return x[1]
+				}
+				panic("unreached")
+			},
+			"Number": func(v interface{}) interface{} {
+				s := ""
+				for _, x := range flat(v) {
+					if x != nil {
+						s += fmt.Sprint(x)
+					}
+				}
+				n, _ := strconv.ParseFloat(s, 64)
+				return n
+			},
+		},
+	})
+
+	for _, tt := range []struct {
+		s      string
+		expect float64
+	}{
+		{"1", 1},
+		{" 1 + 2 * 3", 7},
+		{"(1 + 2) * 3 - -1", 10},
+		{"1.5 * 4 / 3", 2},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := ExpectEof(ps.Start).Parse(charstate.NewState(tt.s))
+			if err != nil {
+				panic(err)
+			}
+			if v != tt.expect {
+				t.Errorf("expect %v actual %v", tt.expect, v)
+			}
+		})
+	}
+
+	kw := ps.Rules["Kw"]
+	for s, ok := range map[string]bool{"SELECT ": true, "selection": false, "xy": true, "y": false} {
+		if _, err := kw.Parse(charstate.NewState(s)); (err == nil) != ok {
+			t.Errorf("%s: expect %v actual %v", s, ok, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		grammar string
+		opts    peg.Options
+		error   string
+	}{
+		{"A <- B", peg.Options{}, "rule `A`: undefined `B`"},
+		{"A <- 'a'", peg.Options{Actions: map[string]func(interface{}) interface{}{"B": nil}}, "action for undefined rule `B`"},
+		{"A <- 'a'\nA <- 'b'", peg.Options{}, "duplicate rule `A`"},
+		{"A <- 'a' )", peg.Options{}, "expect pattern '[A-Za-z_][A-Za-z0-9_]*' in pos 10 line 1 col 10"},
+		{"A <- [a-z]", peg.Options{Primitives: peg.TokPrimitives}, "rule `A`: class [a-z] not supported"},
+		{`A <- 'a' "\q"`, peg.Options{}, `invalid literal "\q": invalid syntax in pos 10 line 1 col 10`},
+		{"A <- ('a'?)*", peg.Options{}, "rule `A`: (\"a\"?)* can repeat without consuming input"},
+		{"A <- B+ 'x'\nB <- 'b'*", peg.Options{}, "rule `A`: B+ can repeat without consuming input"},
+		{"A <- `a*`*", peg.Options{}, "rule `A`: `a*`* can repeat without consuming input"},
+		{"A <- A 'x' / 'x'", peg.Options{}, "rule `A` is left recursive: A -> A"},
+		{"S <- A\nA <- B 'x'\nB <- C? A\nC <- 'c'", peg.Options{}, "rule `A` is left recursive: A -> B -> A"},
+	} {
+		t.Run(tt.error, func(t *testing.T) {
+			g, err := peg.Load(tt.grammar)
+			if err == nil {
+				_, err = g.Build(tt.opts)
+			}
+			if err == nil || err.Error() != tt.error {
+				t.Errorf("expect \"%s\" actual \"%v\"", tt.error, err)
+			}
+		})
+	}
+
+	// parsecgen 与 Build 拒绝同样的文法
+	for _, src := range []string{"A <- ('a'?)*", "A <- A 'x' / 'x'"} {
+		g := peg.MustLoad(src)
+		_, berr := g.Build(peg.Options{})
+		_, gerr := g.Generate(peg.GenOptions{Package: "x"})
+		if berr == nil || gerr == nil || berr.Error() != gerr.Error() {
+			t.Errorf("%s: expect same error actual %v, %v", src, berr, gerr)
+		}
+	}
+}
+
+func flat(v interface{}) []interface{} {
+	xs, ok := v.([]interface{})
+	if !ok {
+		return []interface{}{v}
+	}
+	var acc []interface{}
+	for _, x := range xs {
+		acc = append(acc, flat(x)...)
+	}
+	return acc
+}
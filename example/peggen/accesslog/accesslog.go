@@ -0,0 +1,4 @@
+// Package accesslog parsecgen 从 accesslog.peg 生成的访问日志 parser, 使用 bytestate 的语义
+package accesslog
+
+//go:generate go run ../../../cmd/parsecgen -grammar accesslog.peg -pkg accesslog -state byte -o accesslog_gen.go
@@ -0,0 +1,569 @@
+// Code generated by parsecgen. DO NOT EDIT.
+// source: accesslog.peg
+
+package accesslog
+
+import (
+	"regexp"
+
+	"github.com/goghcrow/parsec"
+)
+
+// Grammar 生成代码使用的文法
+const Grammar = "Log <- Line* !.\nLine <- Host \" \" Field \" \" Field \" [\" Time \"] \" Request \" \" Status \" \" Size Eol\nHost <- `[0-9A-Za-z.:-]+`\nField <- \"-\" / `[^ ]+`\nTime <- `[^\\]\\n]+`\nRequest <- \"\\\"\" Method \" \" Path \" \" Proto \"\\\"\"\nMethod <- \"GET\" / \"POST\" / \"PUT\" / \"DELETE\" / \"HEAD\"\nPath <- `[^ \"\\n]+`\nProto <- `HTTP/[0-9.]+`\nStatus <- `[1-5][0-9][0-9]`\nSize <- \"-\" / `[0-9]+`\nEol <- \"\\n\" / !.\n"
+
+var patterns = []string{
+	"[0-9A-Za-z.:-]+",
+	"[^ ]+",
+	"[^\\]\\n]+",
+	"[^ \"\\n]+",
+	"HTTP/[0-9.]+",
+	"[1-5][0-9][0-9]",
+	"[0-9]+",
+}
+
+var regexes = []*regexp.Regexp{
+	regexp.MustCompile("^(?:[0-9A-Za-z.:-]+)"),
+	regexp.MustCompile("^(?:[^ ]+)"),
+	regexp.MustCompile("^(?:[^\\]\\n]+)"),
+	regexp.MustCompile("^(?:[^ \"\\n]+)"),
+	regexp.MustCompile("^(?:HTTP/[0-9.]+)"),
+	regexp.MustCompile("^(?:[1-5][0-9][0-9])"),
+	regexp.MustCompile("^(?:[0-9]+)"),
+}
+
+// Parser 由 parsecgen 生成, Actions 同 peg.Options.Actions
+type Parser struct {
+	Actions map[string]func(v interface{}) interface{}
+}
+
+// Parse 从开始规则解析 src, 返回结果, 结束位置与错误
+func (p *Parser) Parse(src string) (interface{}, parsec.Pos, error) {
+	s := &state{src: src, actions: p.Actions}
+	s.skip()
+	v, err := s.rule0()
+	if err != nil {
+		return nil, s.Pos, err
+	}
+	return v, s.Pos, nil
+}
+
+type state struct {
+	src string
+	off int // src 中的字节偏移
+	parsec.Pos
+	actions map[string]func(v interface{}) interface{}
+}
+
+type mark struct {
+	parsec.Pos
+	off int
+}
+
+func (s *state) save() mark     { return mark{s.Pos, s.off} }
+func (s *state) restore(m mark) { s.Pos, s.off = m.Pos, m.off }
+
+func (s *state) regex(i int) (interface{}, error) {
+	loc := regexes[i].FindStringIndex(s.src[s.off:])
+	if loc == nil || loc[1] == 0 {
+		return nil, parsec.Trap(s.Pos, "expect pattern '%s'", patterns[i])
+	}
+	v := s.src[s.off : s.off+loc[1]]
+	s.advance(v)
+	return v, nil
+}
+
+func (s *state) skip() {}
+
+func (s *state) step(b byte) {
+	s.Idx++
+	if b == '\n' {
+		s.Line++
+		s.Col = 0
+	} else {
+		s.Col++
+	}
+}
+
+func (s *state) advance(str string) {
+	for i := 0; i < len(str); i++ {
+		s.step(str[i])
+	}
+	s.off += len(str)
+}
+
+func (s *state) expect(expect string) error {
+	if s.off >= len(s.src) {
+		return parsec.Trap(s.Pos, "expect `%s` actual end of input", expect)
+	}
+	return parsec.Trap(s.Pos, "expect `%s` actual `%s`", expect, string(rune(s.src[s.off])))
+}
+
+func (s *state) char(c byte) error {
+	if s.off >= len(s.src) || s.src[s.off] != c {
+		return s.expect(string(rune(c)))
+	}
+	s.off++
+	s.step(c)
+	return nil
+}
+
+func (s *state) any() (interface{}, error) {
+	if s.off >= len(s.src) {
+		return nil, s.expect("any byte")
+	}
+	b := s.src[s.off]
+	s.off++
+	s.step(b)
+	return b, nil
+}
+
+func (s *state) strFold(str string) (interface{}, error) {
+	from := s.off
+	for i := 0; i < len(str); i++ {
+		if s.off >= len(s.src) || lower(s.src[s.off]) != lower(str[i]) {
+			return nil, s.expect(string(rune(str[i])))
+		}
+		s.step(s.src[s.off])
+		s.off++
+	}
+	return s.src[from:s.off], nil
+}
+
+func lower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// Log <- Line* !.
+func (s *state) rule0() (v interface{}, err error) {
+	xs1 := make([]interface{}, 0, 2)
+	{
+		xs2 := []interface{}{}
+		for {
+			save3 := s.save()
+			v, err = s.rule1()
+			if err != nil {
+				s.restore(save3)
+				break
+			}
+			xs2 = append(xs2, v)
+		}
+		v, err = xs2, nil
+	}
+	if err == nil {
+		xs1 = append(xs1, v)
+		save4 := s.save()
+		v, err = s.any()
+		if err == nil {
+			err = parsec.Trap(save4.Pos, "unexpect `%s`", parsec.Show(v))
+		} else {
+			s.restore(save4)
+			err = nil
+		}
+		v = nil
+		if err == nil {
+			xs1 = append(xs1, v)
+			v = xs1
+		}
+	}
+	if err == nil {
+		if f := s.actions["Log"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Line <- Host " " Field " " Field " [" Time "] " Request " " Status " " Size Eol
+func (s *state) rule1() (v interface{}, err error) {
+	xs5 := make([]interface{}, 0, 14)
+	v, err = s.rule2()
+	if err == nil {
+		xs5 = append(xs5, v)
+		// " "
+		err = s.char(' ')
+		v = nil
+		if err == nil {
+			v = " "
+		}
+		if err == nil {
+			xs5 = append(xs5, v)
+			v, err = s.rule3()
+			if err == nil {
+				xs5 = append(xs5, v)
+				// " "
+				err = s.char(' ')
+				v = nil
+				if err == nil {
+					v = " "
+				}
+				if err == nil {
+					xs5 = append(xs5, v)
+					v, err = s.rule3()
+					if err == nil {
+						xs5 = append(xs5, v)
+						// " ["
+						err = s.char(' ')
+						if err == nil {
+							err = s.char('[')
+						}
+						v = nil
+						if err == nil {
+							v = " ["
+						}
+						if err == nil {
+							xs5 = append(xs5, v)
+							v, err = s.rule4()
+							if err == nil {
+								xs5 = append(xs5, v)
+								// "] "
+								err = s.char(']')
+								if err == nil {
+									err = s.char(' ')
+								}
+								v = nil
+								if err == nil {
+									v = "] "
+								}
+								if err == nil {
+									xs5 = append(xs5, v)
+									v, err = s.rule5()
+									if err == nil {
+										xs5 = append(xs5, v)
+										// " "
+										err = s.char(' ')
+										v = nil
+										if err == nil {
+											v = " "
+										}
+										if err == nil {
+											xs5 = append(xs5, v)
+											v, err = s.rule9()
+											if err == nil {
+												xs5 = append(xs5, v)
+												// " "
+												err = s.char(' ')
+												v = nil
+												if err == nil {
+													v = " "
+												}
+												if err == nil {
+													xs5 = append(xs5, v)
+													v, err = s.rule10()
+													if err == nil {
+														xs5 = append(xs5, v)
+														v, err = s.rule11()
+														if err == nil {
+															xs5 = append(xs5, v)
+															v = xs5
+														}
+													}
+												}
+											}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Line"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Host <- `[0-9A-Za-z.:-]+`
+func (s *state) rule2() (v interface{}, err error) {
+	v, err = s.regex(0)
+	if err == nil {
+		if f := s.actions["Host"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Field <- "-" / `[^ ]+`
+func (s *state) rule3() (v interface{}, err error) {
+	save6 := s.save()
+	// "-"
+	err = s.char('-')
+	v = nil
+	if err == nil {
+		v = "-"
+	}
+	if err != nil {
+		s.restore(save6)
+		v, err = s.regex(1)
+	}
+	if err == nil {
+		if f := s.actions["Field"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Time <- `[^\]\n]+`
+func (s *state) rule4() (v interface{}, err error) {
+	v, err = s.regex(2)
+	if err == nil {
+		if f := s.actions["Time"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Request <- "\"" Method " " Path " " Proto "\""
+func (s *state) rule5() (v interface{}, err error) {
+	xs7 := make([]interface{}, 0, 7)
+	// "\""
+	err = s.char('"')
+	v = nil
+	if err == nil {
+		v = "\""
+	}
+	if err == nil {
+		xs7 = append(xs7, v)
+		v, err = s.rule6()
+		if err == nil {
+			xs7 = append(xs7, v)
+			// " "
+			err = s.char(' ')
+			v = nil
+			if err == nil {
+				v = " "
+			}
+			if err == nil {
+				xs7 = append(xs7, v)
+				v, err = s.rule7()
+				if err == nil {
+					xs7 = append(xs7, v)
+					// " "
+					err = s.char(' ')
+					v = nil
+					if err == nil {
+						v = " "
+					}
+					if err == nil {
+						xs7 = append(xs7, v)
+						v, err = s.rule8()
+						if err == nil {
+							xs7 = append(xs7, v)
+							// "\""
+							err = s.char('"')
+							v = nil
+							if err == nil {
+								v = "\""
+							}
+							if err == nil {
+								xs7 = append(xs7, v)
+								v = xs7
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Request"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Method <- "GET" / "POST" / "PUT" / "DELETE" / "HEAD"
+func (s *state) rule6() (v interface{}, err error) {
+	save8 := s.save()
+	// "GET"
+	err = s.char('G')
+	if err == nil {
+		err = s.char('E')
+	}
+	if err == nil {
+		err = s.char('T')
+	}
+	v = nil
+	if err == nil {
+		v = "GET"
+	}
+	if err != nil {
+		s.restore(save8)
+		save9 := s.save()
+		// "POST"
+		err = s.char('P')
+		if err == nil {
+			err = s.char('O')
+		}
+		if err == nil {
+			err = s.char('S')
+		}
+		if err == nil {
+			err = s.char('T')
+		}
+		v = nil
+		if err == nil {
+			v = "POST"
+		}
+		if err != nil {
+			s.restore(save9)
+			save10 := s.save()
+			// "PUT"
+			err = s.char('P')
+			if err == nil {
+				err = s.char('U')
+			}
+			if err == nil {
+				err = s.char('T')
+			}
+			v = nil
+			if err == nil {
+				v = "PUT"
+			}
+			if err != nil {
+				s.restore(save10)
+				save11 := s.save()
+				// "DELETE"
+				err = s.char('D')
+				if err == nil {
+					err = s.char('E')
+				}
+				if err == nil {
+					err = s.char('L')
+				}
+				if err == nil {
+					err = s.char('E')
+				}
+				if err == nil {
+					err = s.char('T')
+				}
+				if err == nil {
+					err = s.char('E')
+				}
+				v = nil
+				if err == nil {
+					v = "DELETE"
+				}
+				if err != nil {
+					s.restore(save11)
+					// "HEAD"
+					err = s.char('H')
+					if err == nil {
+						err = s.char('E')
+					}
+					if err == nil {
+						err = s.char('A')
+					}
+					if err == nil {
+						err = s.char('D')
+					}
+					v = nil
+					if err == nil {
+						v = "HEAD"
+					}
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Method"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Path <- `[^ "\n]+`
+func (s *state) rule7() (v interface{}, err error) {
+	v, err = s.regex(3)
+	if err == nil {
+		if f := s.actions["Path"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Proto <- `HTTP/[0-9.]+`
+func (s *state) rule8() (v interface{}, err error) {
+	v, err = s.regex(4)
+	if err == nil {
+		if f := s.actions["Proto"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Status <- `[1-5][0-9][0-9]`
+func (s *state) rule9() (v interface{}, err error) {
+	v, err = s.regex(5)
+	if err == nil {
+		if f := s.actions["Status"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Size <- "-" / `[0-9]+`
+func (s *state) rule10() (v interface{}, err error) {
+	save12 := s.save()
+	// "-"
+	err = s.char('-')
+	v = nil
+	if err == nil {
+		v = "-"
+	}
+	if err != nil {
+		s.restore(save12)
+		v, err = s.regex(6)
+	}
+	if err == nil {
+		if f := s.actions["Size"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Eol <- "\n" / !.
+func (s *state) rule11() (v interface{}, err error) {
+	save13 := s.save()
+	// "\n"
+	err = s.char('\n')
+	v = nil
+	if err == nil {
+		v = "\n"
+	}
+	if err != nil {
+		s.restore(save13)
+		save14 := s.save()
+		v, err = s.any()
+		if err == nil {
+			err = parsec.Trap(save14.Pos, "unexpect `%s`", parsec.Show(v))
+		} else {
+			s.restore(save14)
+			err = nil
+		}
+		v = nil
+	}
+	if err == nil {
+		if f := s.actions["Eol"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
@@ -0,0 +1,4 @@
+// Package calc parsecgen 从 calc.peg 生成的四则运算 parser
+package calc
+
+//go:generate go run ../../../cmd/parsecgen -grammar calc.peg -pkg calc -state char -skip \s+ -o calc_gen.go
@@ -0,0 +1,627 @@
+// Code generated by parsecgen. DO NOT EDIT.
+// source: calc.peg
+
+package calc
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goghcrow/parsec"
+)
+
+// Grammar 生成代码使用的文法
+const Grammar = "Expr <- Term (AddOp Term)*\nTerm <- Factor (MulOp Factor)*\nFactor <- Number / \"(\" Expr \")\" / \"-\" Factor / Call\nCall <- Fn \"(\" Expr \")\"\nFn <- \"abs\"i !`\\w` / \"√\"\nAddOp <- \"+\" / \"-\"\nMulOp <- \"*\" / \"/\" / \"×\" / \"÷\"\nNumber <- [0-9]+ (\".\" [0-9]+)? !\".\"\nRest <- &\"#\" .*\n"
+
+var patterns = []string{
+	"\\s+",
+	"\\w",
+	"[0-9]",
+}
+
+var regexes = []*regexp.Regexp{
+	regexp.MustCompile("^(?:\\s+)"),
+	regexp.MustCompile("^(?:\\w)"),
+	regexp.MustCompile("^(?:[0-9])"),
+}
+
+// Parser 由 parsecgen 生成, Actions 同 peg.Options.Actions
+type Parser struct {
+	Actions map[string]func(v interface{}) interface{}
+}
+
+// Parse 从开始规则解析 src, 返回结果, 结束位置与错误
+func (p *Parser) Parse(src string) (interface{}, parsec.Pos, error) {
+	s := &state{src: src, actions: p.Actions}
+	s.skip()
+	v, err := s.rule0()
+	if err != nil {
+		return nil, s.Pos, err
+	}
+	return v, s.Pos, nil
+}
+
+type state struct {
+	src string
+	off int // src 中的字节偏移
+	parsec.Pos
+	actions map[string]func(v interface{}) interface{}
+}
+
+type mark struct {
+	parsec.Pos
+	off int
+}
+
+func (s *state) save() mark     { return mark{s.Pos, s.off} }
+func (s *state) restore(m mark) { s.Pos, s.off = m.Pos, m.off }
+
+func (s *state) regex(i int) (interface{}, error) {
+	loc := regexes[i].FindStringIndex(s.src[s.off:])
+	if loc == nil || loc[1] == 0 {
+		return nil, parsec.Trap(s.Pos, "expect pattern '%s'", patterns[i])
+	}
+	v := s.src[s.off : s.off+loc[1]]
+	s.advance(v)
+	return v, nil
+}
+
+func (s *state) skip() {
+	if loc := regexes[0].FindStringIndex(s.src[s.off:]); loc != nil && loc[1] > 0 {
+		s.advance(s.src[s.off : s.off+loc[1]])
+	}
+}
+
+func (s *state) step(r rune) {
+	s.Idx++
+	if r == '\n' {
+		s.Line++
+		s.Col = 0
+	} else {
+		s.Col++
+	}
+}
+
+func (s *state) advance(str string) {
+	for _, r := range str {
+		s.step(r)
+	}
+	s.off += len(str)
+}
+
+func (s *state) expect(expect string) error {
+	r, n := utf8.DecodeRuneInString(s.src[s.off:])
+	if n == 0 {
+		return parsec.Trap(s.Pos, "expect `%s` actual end of input", expect)
+	}
+	return parsec.Trap(s.Pos, "expect `%s` actual `%s`", expect, string(r))
+}
+
+func (s *state) char(c rune) error {
+	r, n := utf8.DecodeRuneInString(s.src[s.off:])
+	if n == 0 || r != c {
+		return s.expect(string(c))
+	}
+	s.off += n
+	s.step(r)
+	return nil
+}
+
+func (s *state) any() (interface{}, error) {
+	r, n := utf8.DecodeRuneInString(s.src[s.off:])
+	if n == 0 {
+		return nil, s.expect("any rune")
+	}
+	s.off += n
+	s.step(r)
+	return r, nil
+}
+
+func (s *state) strFold(str string) (interface{}, error) {
+	from := s.off
+	for _, c := range str {
+		r, n := utf8.DecodeRuneInString(s.src[s.off:])
+		if n == 0 || !equalFold(c, r) {
+			return nil, s.expect(string(c))
+		}
+		s.off += n
+		s.step(r)
+	}
+	return s.src[from:s.off], nil
+}
+
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Expr <- Term (AddOp Term)*
+func (s *state) rule0() (v interface{}, err error) {
+	xs1 := make([]interface{}, 0, 2)
+	v, err = s.rule1()
+	if err == nil {
+		xs1 = append(xs1, v)
+		{
+			xs2 := []interface{}{}
+			for {
+				save3 := s.save()
+				xs4 := make([]interface{}, 0, 2)
+				v, err = s.rule5()
+				if err == nil {
+					xs4 = append(xs4, v)
+					v, err = s.rule1()
+					if err == nil {
+						xs4 = append(xs4, v)
+						v = xs4
+					}
+				}
+				if err != nil {
+					s.restore(save3)
+					break
+				}
+				xs2 = append(xs2, v)
+			}
+			v, err = xs2, nil
+		}
+		if err == nil {
+			xs1 = append(xs1, v)
+			v = xs1
+		}
+	}
+	if err == nil {
+		if f := s.actions["Expr"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Term <- Factor (MulOp Factor)*
+func (s *state) rule1() (v interface{}, err error) {
+	xs5 := make([]interface{}, 0, 2)
+	v, err = s.rule2()
+	if err == nil {
+		xs5 = append(xs5, v)
+		{
+			xs6 := []interface{}{}
+			for {
+				save7 := s.save()
+				xs8 := make([]interface{}, 0, 2)
+				v, err = s.rule6()
+				if err == nil {
+					xs8 = append(xs8, v)
+					v, err = s.rule2()
+					if err == nil {
+						xs8 = append(xs8, v)
+						v = xs8
+					}
+				}
+				if err != nil {
+					s.restore(save7)
+					break
+				}
+				xs6 = append(xs6, v)
+			}
+			v, err = xs6, nil
+		}
+		if err == nil {
+			xs5 = append(xs5, v)
+			v = xs5
+		}
+	}
+	if err == nil {
+		if f := s.actions["Term"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Factor <- Number / "(" Expr ")" / "-" Factor / Call
+func (s *state) rule2() (v interface{}, err error) {
+	save9 := s.save()
+	v, err = s.rule7()
+	if err != nil {
+		s.restore(save9)
+		save10 := s.save()
+		xs11 := make([]interface{}, 0, 3)
+		// "("
+		err = s.char('(')
+		v = nil
+		if err == nil {
+			v = "("
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs11 = append(xs11, v)
+			v, err = s.rule0()
+			if err == nil {
+				xs11 = append(xs11, v)
+				// ")"
+				err = s.char(')')
+				v = nil
+				if err == nil {
+					v = ")"
+				}
+				if err == nil {
+					s.skip()
+				}
+				if err == nil {
+					xs11 = append(xs11, v)
+					v = xs11
+				}
+			}
+		}
+		if err != nil {
+			s.restore(save10)
+			save12 := s.save()
+			xs13 := make([]interface{}, 0, 2)
+			// "-"
+			err = s.char('-')
+			v = nil
+			if err == nil {
+				v = "-"
+			}
+			if err == nil {
+				s.skip()
+			}
+			if err == nil {
+				xs13 = append(xs13, v)
+				v, err = s.rule2()
+				if err == nil {
+					xs13 = append(xs13, v)
+					v = xs13
+				}
+			}
+			if err != nil {
+				s.restore(save12)
+				v, err = s.rule3()
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Factor"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Call <- Fn "(" Expr ")"
+func (s *state) rule3() (v interface{}, err error) {
+	xs14 := make([]interface{}, 0, 4)
+	v, err = s.rule4()
+	if err == nil {
+		xs14 = append(xs14, v)
+		// "("
+		err = s.char('(')
+		v = nil
+		if err == nil {
+			v = "("
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs14 = append(xs14, v)
+			v, err = s.rule0()
+			if err == nil {
+				xs14 = append(xs14, v)
+				// ")"
+				err = s.char(')')
+				v = nil
+				if err == nil {
+					v = ")"
+				}
+				if err == nil {
+					s.skip()
+				}
+				if err == nil {
+					xs14 = append(xs14, v)
+					v = xs14
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Call"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Fn <- "abs"i !`\w` / "√"
+func (s *state) rule4() (v interface{}, err error) {
+	save15 := s.save()
+	xs16 := make([]interface{}, 0, 2)
+	v, err = s.strFold("abs")
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		xs16 = append(xs16, v)
+		save17 := s.save()
+		v, err = s.regex(1)
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			err = parsec.Trap(save17.Pos, "unexpect `%s`", parsec.Show(v))
+		} else {
+			s.restore(save17)
+			err = nil
+		}
+		v = nil
+		if err == nil {
+			xs16 = append(xs16, v)
+			v = xs16
+		}
+	}
+	if err != nil {
+		s.restore(save15)
+		// "√"
+		err = s.char('√')
+		v = nil
+		if err == nil {
+			v = "√"
+		}
+		if err == nil {
+			s.skip()
+		}
+	}
+	if err == nil {
+		if f := s.actions["Fn"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// AddOp <- "+" / "-"
+func (s *state) rule5() (v interface{}, err error) {
+	save18 := s.save()
+	// "+"
+	err = s.char('+')
+	v = nil
+	if err == nil {
+		v = "+"
+	}
+	if err == nil {
+		s.skip()
+	}
+	if err != nil {
+		s.restore(save18)
+		// "-"
+		err = s.char('-')
+		v = nil
+		if err == nil {
+			v = "-"
+		}
+		if err == nil {
+			s.skip()
+		}
+	}
+	if err == nil {
+		if f := s.actions["AddOp"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// MulOp <- "*" / "/" / "×" / "÷"
+func (s *state) rule6() (v interface{}, err error) {
+	save19 := s.save()
+	// "*"
+	err = s.char('*')
+	v = nil
+	if err == nil {
+		v = "*"
+	}
+	if err == nil {
+		s.skip()
+	}
+	if err != nil {
+		s.restore(save19)
+		save20 := s.save()
+		// "/"
+		err = s.char('/')
+		v = nil
+		if err == nil {
+			v = "/"
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err != nil {
+			s.restore(save20)
+			save21 := s.save()
+			// "×"
+			err = s.char('×')
+			v = nil
+			if err == nil {
+				v = "×"
+			}
+			if err == nil {
+				s.skip()
+			}
+			if err != nil {
+				s.restore(save21)
+				// "÷"
+				err = s.char('÷')
+				v = nil
+				if err == nil {
+					v = "÷"
+				}
+				if err == nil {
+					s.skip()
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["MulOp"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Number <- [0-9]+ ("." [0-9]+)? !"."
+func (s *state) rule7() (v interface{}, err error) {
+	xs22 := make([]interface{}, 0, 3)
+	v, err = s.regex(2)
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		xs23 := []interface{}{v}
+		for {
+			save24 := s.save()
+			v, err = s.regex(2)
+			if err == nil {
+				s.skip()
+			}
+			if err != nil {
+				s.restore(save24)
+				break
+			}
+			xs23 = append(xs23, v)
+		}
+		v, err = xs23, nil
+	}
+	if err == nil {
+		xs22 = append(xs22, v)
+		save25 := s.save()
+		xs26 := make([]interface{}, 0, 2)
+		// "."
+		err = s.char('.')
+		v = nil
+		if err == nil {
+			v = "."
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs26 = append(xs26, v)
+			v, err = s.regex(2)
+			if err == nil {
+				s.skip()
+			}
+			if err == nil {
+				xs27 := []interface{}{v}
+				for {
+					save28 := s.save()
+					v, err = s.regex(2)
+					if err == nil {
+						s.skip()
+					}
+					if err != nil {
+						s.restore(save28)
+						break
+					}
+					xs27 = append(xs27, v)
+				}
+				v, err = xs27, nil
+			}
+			if err == nil {
+				xs26 = append(xs26, v)
+				v = xs26
+			}
+		}
+		if err != nil {
+			s.restore(save25)
+			v, err = nil, nil
+		}
+		if err == nil {
+			xs22 = append(xs22, v)
+			save29 := s.save()
+			// "."
+			err = s.char('.')
+			v = nil
+			if err == nil {
+				v = "."
+			}
+			if err == nil {
+				s.skip()
+			}
+			if err == nil {
+				err = parsec.Trap(save29.Pos, "unexpect `%s`", parsec.Show(v))
+			} else {
+				s.restore(save29)
+				err = nil
+			}
+			v = nil
+			if err == nil {
+				xs22 = append(xs22, v)
+				v = xs22
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Number"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Rest <- &"#" .*
+func (s *state) rule8() (v interface{}, err error) {
+	xs30 := make([]interface{}, 0, 2)
+	save31 := s.save()
+	// "#"
+	err = s.char('#')
+	v = nil
+	if err == nil {
+		v = "#"
+	}
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		s.restore(save31)
+	}
+	v = nil
+	if err == nil {
+		xs30 = append(xs30, v)
+		{
+			xs32 := []interface{}{}
+			for {
+				save33 := s.save()
+				v, err = s.any()
+				if err == nil {
+					s.skip()
+				}
+				if err != nil {
+					s.restore(save33)
+					break
+				}
+				xs32 = append(xs32, v)
+			}
+			v, err = xs32, nil
+		}
+		if err == nil {
+			xs30 = append(xs30, v)
+			v = xs30
+		}
+	}
+	if err == nil {
+		if f := s.actions["Rest"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
@@ -0,0 +1,4 @@
+// Package ini parsecgen 从 ini.peg 生成的 ini 文件 parser, 使用 bytestate 的语义
+package ini
+
+//go:generate go run ../../../cmd/parsecgen -grammar ini.peg -pkg ini -state byte -skip [\t\x20]+ -o ini_gen.go
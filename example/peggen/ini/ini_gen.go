@@ -0,0 +1,449 @@
+// Code generated by parsecgen. DO NOT EDIT.
+// source: ini.peg
+
+package ini
+
+import (
+	"regexp"
+
+	"github.com/goghcrow/parsec"
+)
+
+// Grammar 生成代码使用的文法
+const Grammar = "File <- (Blank / Section / Pair)* !.\nSection <- \"[\" Name \"]\" Eol\nPair <- Name \"=\" Value Eol\nName <- `[A-Za-z_][A-Za-z0-9_.]*`\nValue <- (\"true\"i / \"false\"i) &Eol / `[^;\\n]*`\nBlank <- (\";\" `[^\\n]*`)? \"\\n\"\nEol <- (\";\" `[^\\n]*`)? (\"\\n\" / !.)\n"
+
+var patterns = []string{
+	"[\\t\\x20]+",
+	"[A-Za-z_][A-Za-z0-9_.]*",
+	"[^;\\n]*",
+	"[^\\n]*",
+}
+
+var regexes = []*regexp.Regexp{
+	regexp.MustCompile("^(?:[\\t\\x20]+)"),
+	regexp.MustCompile("^(?:[A-Za-z_][A-Za-z0-9_.]*)"),
+	regexp.MustCompile("^(?:[^;\\n]*)"),
+	regexp.MustCompile("^(?:[^\\n]*)"),
+}
+
+// Parser 由 parsecgen 生成, Actions 同 peg.Options.Actions
+type Parser struct {
+	Actions map[string]func(v interface{}) interface{}
+}
+
+// Parse 从开始规则解析 src, 返回结果, 结束位置与错误
+func (p *Parser) Parse(src string) (interface{}, parsec.Pos, error) {
+	s := &state{src: src, actions: p.Actions}
+	s.skip()
+	v, err := s.rule0()
+	if err != nil {
+		return nil, s.Pos, err
+	}
+	return v, s.Pos, nil
+}
+
+type state struct {
+	src string
+	off int // src 中的字节偏移
+	parsec.Pos
+	actions map[string]func(v interface{}) interface{}
+}
+
+type mark struct {
+	parsec.Pos
+	off int
+}
+
+func (s *state) save() mark     { return mark{s.Pos, s.off} }
+func (s *state) restore(m mark) { s.Pos, s.off = m.Pos, m.off }
+
+func (s *state) regex(i int) (interface{}, error) {
+	loc := regexes[i].FindStringIndex(s.src[s.off:])
+	if loc == nil || loc[1] == 0 {
+		return nil, parsec.Trap(s.Pos, "expect pattern '%s'", patterns[i])
+	}
+	v := s.src[s.off : s.off+loc[1]]
+	s.advance(v)
+	return v, nil
+}
+
+func (s *state) skip() {
+	if loc := regexes[0].FindStringIndex(s.src[s.off:]); loc != nil && loc[1] > 0 {
+		s.advance(s.src[s.off : s.off+loc[1]])
+	}
+}
+
+func (s *state) step(b byte) {
+	s.Idx++
+	if b == '\n' {
+		s.Line++
+		s.Col = 0
+	} else {
+		s.Col++
+	}
+}
+
+func (s *state) advance(str string) {
+	for i := 0; i < len(str); i++ {
+		s.step(str[i])
+	}
+	s.off += len(str)
+}
+
+func (s *state) expect(expect string) error {
+	if s.off >= len(s.src) {
+		return parsec.Trap(s.Pos, "expect `%s` actual end of input", expect)
+	}
+	return parsec.Trap(s.Pos, "expect `%s` actual `%s`", expect, string(rune(s.src[s.off])))
+}
+
+func (s *state) char(c byte) error {
+	if s.off >= len(s.src) || s.src[s.off] != c {
+		return s.expect(string(rune(c)))
+	}
+	s.off++
+	s.step(c)
+	return nil
+}
+
+func (s *state) any() (interface{}, error) {
+	if s.off >= len(s.src) {
+		return nil, s.expect("any byte")
+	}
+	b := s.src[s.off]
+	s.off++
+	s.step(b)
+	return b, nil
+}
+
+func (s *state) strFold(str string) (interface{}, error) {
+	from := s.off
+	for i := 0; i < len(str); i++ {
+		if s.off >= len(s.src) || lower(s.src[s.off]) != lower(str[i]) {
+			return nil, s.expect(string(rune(str[i])))
+		}
+		s.step(s.src[s.off])
+		s.off++
+	}
+	return s.src[from:s.off], nil
+}
+
+func lower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// File <- (Blank / Section / Pair)* !.
+func (s *state) rule0() (v interface{}, err error) {
+	xs1 := make([]interface{}, 0, 2)
+	{
+		xs2 := []interface{}{}
+		for {
+			save3 := s.save()
+			save4 := s.save()
+			v, err = s.rule5()
+			if err != nil {
+				s.restore(save4)
+				save5 := s.save()
+				v, err = s.rule1()
+				if err != nil {
+					s.restore(save5)
+					v, err = s.rule2()
+				}
+			}
+			if err != nil {
+				s.restore(save3)
+				break
+			}
+			xs2 = append(xs2, v)
+		}
+		v, err = xs2, nil
+	}
+	if err == nil {
+		xs1 = append(xs1, v)
+		save6 := s.save()
+		v, err = s.any()
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			err = parsec.Trap(save6.Pos, "unexpect `%s`", parsec.Show(v))
+		} else {
+			s.restore(save6)
+			err = nil
+		}
+		v = nil
+		if err == nil {
+			xs1 = append(xs1, v)
+			v = xs1
+		}
+	}
+	if err == nil {
+		if f := s.actions["File"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Section <- "[" Name "]" Eol
+func (s *state) rule1() (v interface{}, err error) {
+	xs7 := make([]interface{}, 0, 4)
+	// "["
+	err = s.char('[')
+	v = nil
+	if err == nil {
+		v = "["
+	}
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		xs7 = append(xs7, v)
+		v, err = s.rule3()
+		if err == nil {
+			xs7 = append(xs7, v)
+			// "]"
+			err = s.char(']')
+			v = nil
+			if err == nil {
+				v = "]"
+			}
+			if err == nil {
+				s.skip()
+			}
+			if err == nil {
+				xs7 = append(xs7, v)
+				v, err = s.rule6()
+				if err == nil {
+					xs7 = append(xs7, v)
+					v = xs7
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Section"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Pair <- Name "=" Value Eol
+func (s *state) rule2() (v interface{}, err error) {
+	xs8 := make([]interface{}, 0, 4)
+	v, err = s.rule3()
+	if err == nil {
+		xs8 = append(xs8, v)
+		// "="
+		err = s.char('=')
+		v = nil
+		if err == nil {
+			v = "="
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs8 = append(xs8, v)
+			v, err = s.rule4()
+			if err == nil {
+				xs8 = append(xs8, v)
+				v, err = s.rule6()
+				if err == nil {
+					xs8 = append(xs8, v)
+					v = xs8
+				}
+			}
+		}
+	}
+	if err == nil {
+		if f := s.actions["Pair"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Name <- `[A-Za-z_][A-Za-z0-9_.]*`
+func (s *state) rule3() (v interface{}, err error) {
+	v, err = s.regex(1)
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		if f := s.actions["Name"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Value <- ("true"i / "false"i) &Eol / `[^;\n]*`
+func (s *state) rule4() (v interface{}, err error) {
+	save9 := s.save()
+	xs10 := make([]interface{}, 0, 2)
+	save11 := s.save()
+	v, err = s.strFold("true")
+	if err == nil {
+		s.skip()
+	}
+	if err != nil {
+		s.restore(save11)
+		v, err = s.strFold("false")
+		if err == nil {
+			s.skip()
+		}
+	}
+	if err == nil {
+		xs10 = append(xs10, v)
+		save12 := s.save()
+		v, err = s.rule6()
+		if err == nil {
+			s.restore(save12)
+		}
+		v = nil
+		if err == nil {
+			xs10 = append(xs10, v)
+			v = xs10
+		}
+	}
+	if err != nil {
+		s.restore(save9)
+		v, err = s.regex(2)
+		if err == nil {
+			s.skip()
+		}
+	}
+	if err == nil {
+		if f := s.actions["Value"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Blank <- (";" `[^\n]*`)? "\n"
+func (s *state) rule5() (v interface{}, err error) {
+	xs13 := make([]interface{}, 0, 2)
+	save14 := s.save()
+	xs15 := make([]interface{}, 0, 2)
+	// ";"
+	err = s.char(';')
+	v = nil
+	if err == nil {
+		v = ";"
+	}
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		xs15 = append(xs15, v)
+		v, err = s.regex(3)
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs15 = append(xs15, v)
+			v = xs15
+		}
+	}
+	if err != nil {
+		s.restore(save14)
+		v, err = nil, nil
+	}
+	if err == nil {
+		xs13 = append(xs13, v)
+		// "\n"
+		err = s.char('\n')
+		v = nil
+		if err == nil {
+			v = "\n"
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs13 = append(xs13, v)
+			v = xs13
+		}
+	}
+	if err == nil {
+		if f := s.actions["Blank"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
+
+// Eol <- (";" `[^\n]*`)? ("\n" / !.)
+func (s *state) rule6() (v interface{}, err error) {
+	xs16 := make([]interface{}, 0, 2)
+	save17 := s.save()
+	xs18 := make([]interface{}, 0, 2)
+	// ";"
+	err = s.char(';')
+	v = nil
+	if err == nil {
+		v = ";"
+	}
+	if err == nil {
+		s.skip()
+	}
+	if err == nil {
+		xs18 = append(xs18, v)
+		v, err = s.regex(3)
+		if err == nil {
+			s.skip()
+		}
+		if err == nil {
+			xs18 = append(xs18, v)
+			v = xs18
+		}
+	}
+	if err != nil {
+		s.restore(save17)
+		v, err = nil, nil
+	}
+	if err == nil {
+		xs16 = append(xs16, v)
+		save19 := s.save()
+		// "\n"
+		err = s.char('\n')
+		v = nil
+		if err == nil {
+			v = "\n"
+		}
+		if err == nil {
+			s.skip()
+		}
+		if err != nil {
+			s.restore(save19)
+			save20 := s.save()
+			v, err = s.any()
+			if err == nil {
+				s.skip()
+			}
+			if err == nil {
+				err = parsec.Trap(save20.Pos, "unexpect `%s`", parsec.Show(v))
+			} else {
+				s.restore(save20)
+				err = nil
+			}
+			v = nil
+		}
+		if err == nil {
+			xs16 = append(xs16, v)
+			v = xs16
+		}
+	}
+	if err == nil {
+		if f := s.actions["Eol"]; f != nil {
+			v = f(v)
+		}
+	}
+	return
+}
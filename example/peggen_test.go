@@ -0,0 +1,102 @@
+package example
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/example/peggen/accesslog"
+	"github.com/goghcrow/parsec/example/peggen/calc"
+	"github.com/goghcrow/parsec/example/peggen/ini"
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/bytestate"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+// 生成的 parser 与 peg.Build 解释执行的结果, 错误, 结束位置必须完全一致
+func TestGeneratedParser(t *testing.T) {
+	calcActions := map[string]func(v interface{}) interface{}{
+		"Number": func(v interface{}) interface{} {
+			s := ""
+			for _, d := range v.([]interface{})[0].([]interface{}) {
+				s += d.(string)
+			}
+			n, _ := strconv.Atoi(s)
+			return n
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		grammar  string
+		opts     peg.Options
+		newState func(string) State
+		gen      func(string) (interface{}, Pos, error)
+		inputs   []string
+	}{
+		{
+			name:     "calc",
+			grammar:  calc.Grammar,
+			opts:     peg.Options{Skip: Optional(charstate.Regex(`\s+`))},
+			newState: charstate.NewState,
+			gen:      (&calc.Parser{}).Parse,
+			inputs: []string{
+				"1", " 1 + 2 * 3 ", "(1 + 2) × 3 ÷ 4", "-(1.5 - -2)", "ABS(1) + √(4)", "Abs (1)",
+				"", "1 +", "1 + * 2", "(1 + 2", "1.", "1..2", "absx(1)", "√\n(\n", "你好",
+			},
+		},
+		{
+			name:     "calc actions",
+			grammar:  calc.Grammar,
+			opts:     peg.Options{Skip: Optional(charstate.Regex(`\s+`)), Actions: calcActions},
+			newState: charstate.NewState,
+			gen:      (&calc.Parser{Actions: calcActions}).Parse,
+			inputs:   []string{"12 * (3 + 45)", "1 + x"},
+		},
+		{
+			name:     "ini",
+			grammar:  ini.Grammar,
+			opts:     peg.Options{Primitives: peg.BytePrimitives, Skip: Optional(bytestate.Regex(`[\t\x20]+`))},
+			newState: bytestate.NewState,
+			gen:      (&ini.Parser{}).Parse,
+			inputs: []string{
+				"", "[main]\nname = x ; comment\n\n; line comment\ndebug = TRUE\n", "a.b=1", "flag = false ;",
+				"[main\n", "a = \n", "= 1", "[sec]\na = 1\n[", "a = 1\nb = é",
+			},
+		},
+		{
+			name:     "accesslog",
+			grammar:  accesslog.Grammar,
+			opts:     peg.Options{Primitives: peg.BytePrimitives},
+			newState: bytestate.NewState,
+			gen:      (&accesslog.Parser{}).Parse,
+			inputs: []string{
+				"",
+				"127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] \"GET /a.gif HTTP/1.0\" 200 2326\n" +
+					"::1 - - [10/Oct/2000:13:55:37 -0700] \"POST /api HTTP/1.1\" 404 -",
+				"127.0.0.1 - - [x] \"PATCH / HTTP/1.1\" 200 1",
+				"127.0.0.1 - - [x] \"GET / HTTP/1.1\" 600 1\n",
+				"127.0.0.1 - - [x] \"GET / HTTP/1.1\" 200 1 x",
+			},
+		},
+	} {
+		ps := peg.MustLoad(tt.grammar).MustBuild(tt.opts)
+		for _, in := range tt.inputs {
+			t.Run(tt.name+" "+strconv.Quote(in), func(t *testing.T) {
+				s := tt.newState(in)
+				v, err := ps.Start.Parse(s)
+				gv, gpos, gerr := tt.gen(in)
+				if !reflect.DeepEqual(v, gv) {
+					t.Errorf("expect %#v actual %#v", v, gv)
+				}
+				if (err == nil) != (gerr == nil) || err != nil && !reflect.DeepEqual(err, gerr) {
+					t.Errorf("expect error %v actual %v", err, gerr)
+				}
+				if s.Save() != gpos {
+					t.Errorf("expect end %s actual %s", s.Save(), gpos)
+				}
+			})
+		}
+	}
+}
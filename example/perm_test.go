@@ -0,0 +1,96 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/permparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+// config 块中字段顺序任意, tags 可选
+//
+//	config = "{" permute (name <||> version <|?> ([], tags)) "}"
+func TestPermutation(t *testing.T) {
+	field := func(key string, val Parser) Parser {
+		return Trim(Right(Str(key), Right(Trim(Char('='), Space), val)), Space)
+	}
+	name := field("name", Ident)
+	version := field("version", Regex(`\d+`))
+	tags := field("tags", Mid(Char('['), SepBy(Ident, Trim(Char(','), Space)), Char(']')))
+
+	config := Mid(Char('{'), NewPerm().
+		Required("name", name).
+		Required("version", version).
+		Optional("tags", tags, []interface{}{}), Char('}'))
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+		error  string
+	}{
+		{s: "{name = a version = 1 tags = [x, y]}", expect: "[a 1 [x y]]"},
+		{s: "{tags = [x] version = 1 name = a}", expect: "[a 1 [x]]"},
+		{s: "{version = 1 name = a}", expect: "[a 1 []]"},
+		{s: "{name = a}", error: "missing `version` in pos 10 line 1 col 10"},
+		{s: "{}", error: "missing `name`, `version` in pos 2 line 1 col 2"},
+		{s: "{name = a version = 1 name = b}", error: "duplicate `name` in pos 23 line 1 col 23"},
+		{s: "{name = a version = x}", error: "expect pattern '\\d+' in pos 21 line 1 col 21"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := config.Parse(NewState(tt.s))
+			if err != nil {
+				if tt.error == "" {
+					t.Fatalf("unexpected error %s", err)
+				}
+				actual := err.Error()
+				if actual != tt.error {
+					t.Errorf("expect \"%s\" actual \"%s\"", tt.error, actual)
+				}
+				return
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+			}
+		})
+	}
+}
+
+// 可以不消费 state 成功的组件 (Option Many), 只在消费 state 时算作出现
+func TestPermutationNullable(t *testing.T) {
+	perm := ExpectEof(NewPerm().
+		Optional("b", Option(Str("b"), "-"), "x").
+		Required("a", Str("a")).
+		Required("c", Many(Str("c"))))
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+		error  string
+	}{
+		{s: "a", expect: "[- a []]"},
+		{s: "ab", expect: "[b a []]"},
+		{s: "cca", expect: "[- a [c c]]"},
+		{s: "bca", expect: "[b a [c]]"},
+		{s: "abb", error: "duplicate `b` in pos 3 line 1 col 3"},
+		{s: "b", error: "missing `a` in pos 2 line 1 col 2"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := perm.Parse(NewState(tt.s))
+			if err != nil {
+				if tt.error == "" {
+					t.Fatalf("unexpected error %s", err)
+				}
+				if err.Error() != tt.error {
+					t.Errorf("expect \"%s\" actual \"%s\"", tt.error, err.Error())
+				}
+				return
+			}
+			if actual := fmt.Sprint(v); actual != tt.expect {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+			}
+		})
+	}
+}
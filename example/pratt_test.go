@@ -0,0 +1,173 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+// 生成 s-expr 形式的 ast, 方便观察结合性
+func TestPratt(t *testing.T) {
+	tok := func(s string) Parser { return Trim(Str(s), Space) }
+	unary := func(s string) Parser {
+		return tok(s).Map(func(op interface{}) interface{} {
+			return func(x interface{}) interface{} { return fmt.Sprintf("(%s %s)", op, x) }
+		})
+	}
+	binary := func(s string) Parser {
+		return tok(s).Map(func(op interface{}) interface{} {
+			return func(l, r interface{}) interface{} { return fmt.Sprintf("(%s %s %s)", op, l, r) }
+		})
+	}
+
+	term := Trim(Alt(Ident, Regex(`\d+`)), Space)
+	Expr := NewRule()
+	expr := NewPratt(Alt(Mid(tok("("), Expr, tok(")")), term))
+	Expr.Pattern = expr
+
+	// 有公共前缀的操作符, 长的先注册
+	expr.
+		Postfix(7, unary("++")).
+		Led(1, tok("?"), func(_, c interface{}) Parser {
+			return List(expr.Expr(0), tok(":"), expr.Expr(0)).Map(func(v interface{}) interface{} {
+				xs := v.([]interface{})
+				return fmt.Sprintf("(? %s %s %s)", c, xs[0], xs[2])
+			})
+		}).
+		InfixN(2, binary("==")).
+		InfixL(3, binary("+")).
+		InfixL(3, binary("-")).
+		InfixL(4, binary("*")).
+		InfixR(5, binary("^")).
+		Prefix(6, unary("-")).
+		Prefix(6, unary("!")).
+		Led(8, tok("["), func(_, lhs interface{}) Parser {
+			return Left(expr.Expr(0), tok("]")).Map(func(idx interface{}) interface{} {
+				return fmt.Sprintf("([] %s %s)", lhs, idx)
+			})
+		}).
+		Led(8, tok("("), func(_, lhs interface{}) Parser {
+			return Left(SepBy(expr.Expr(0), tok(",")), tok(")")).Map(func(args interface{}) interface{} {
+				return fmt.Sprintf("(call %s %s)", lhs, args)
+			})
+		})
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+		error  string
+	}{
+		{s: "1 + 2 * 3", expect: "(+ 1 (* 2 3))"},
+		{s: "1 - 2 - 3", expect: "(- (- 1 2) 3)"},
+		{s: "2 ^ 3 ^ 4", expect: "(^ 2 (^ 3 4))"},
+		{s: "(1 + 2) * 3", expect: "(* (+ 1 2) 3)"},
+		{s: "- - 1", expect: "(- (- 1))"},
+		{s: "!-a", expect: "(! (- a))"},
+		{s: "-a ^ b", expect: "(^ (- a) b)"},
+		{s: "a++ ++", expect: "(++ (++ a))"},
+		{s: "-a++", expect: "(- (++ a))"},
+		{s: "a == b + 1", expect: "(== a (+ b 1))"},
+		{s: "c ? a : b ? x : y", expect: "(? c a (? b x y))"},
+		{s: "a + b ? 1 : 2", expect: "(? (+ a b) 1 2)"},
+		{s: "a[1][b + 1]", expect: "([] ([] a 1) (+ b 1))"},
+		{s: "f(1, g(x))[0]", expect: "([] (call f [1 (call g [x])]) 0)"},
+		{s: "a == b == c", error: "operator `==` is non-associative, add parentheses in pos 8 line 1 col 8"},
+		{s: "a + * b", error: "expect expression after `+` in pos 5 line 1 col 5"},
+		{s: "a[", error: "expect expression after `[` in pos 3 line 1 col 3"},
+		{s: "a[1", error: "expect `]` actual end of input in pos 4 line 1 col 4"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := ExpectEof(Expr).Parse(NewState(tt.s))
+			if err != nil {
+				actual := err.Error()
+				if actual != tt.error {
+					t.Errorf("expect \"%s\" actual \"%s\"", tt.error, actual)
+				}
+				return
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+			}
+		})
+	}
+}
+
+func TestBuildPrattParser(t *testing.T) {
+	tok := func(s string) Parser { return Trim(Str(s), Space) }
+	binary := func(s string, assoc Assoc) Operator {
+		return Operator{OperKind: Infix, Assoc: assoc, Parser: tok(s).Map(func(op interface{}) interface{} {
+			return func(l, r interface{}) interface{} { return fmt.Sprintf("(%s %s %s)", op, l, r) }
+		})}
+	}
+	prefix := func(s string) Operator {
+		return NewPrefix(tok(s).Map(func(op interface{}) interface{} {
+			return func(x interface{}) interface{} { return fmt.Sprintf("(%s %s)", op, x) }
+		}))
+	}
+	table := OperatorTable{
+		{prefix("-")},
+		{binary("^", AssocRight)},
+		{binary("*", AssocLeft), binary("/", AssocLeft)},
+		{binary("+", AssocLeft), binary("-", AssocLeft)},
+		{binary("<", AssocNone)},
+	}
+	term := Trim(Regex(`\d+`), Space)
+	expr := ExpectEof(BuildExpressionParser(table, term))
+	pratt := ExpectEof(BuildPrattParser(table, term))
+
+	for _, s := range []string{
+		"1",
+		"-1 + 2",
+		"1 - 2 - 3 * 4 / 5",
+		"2 ^ 3 ^ -4 * 5",
+		"1 + 2 < 3 * 4",
+	} {
+		t.Run(s, func(t *testing.T) {
+			expect, err := expr.Parse(NewState(s))
+			if err != nil {
+				panic(err)
+			}
+			actual, err := pratt.Parse(NewState(s))
+			if err != nil {
+				panic(err)
+			}
+			if expect != actual {
+				t.Errorf("expect \"%s\" actual \"%s\"", expect, actual)
+			}
+		})
+	}
+
+	// 同一层混用不同结合性的中缀操作符, 两者报相同的歧义
+	mixed := OperatorTable{{binary("+", AssocLeft), binary("==", AssocNone), binary("^", AssocRight)}}
+	term = Trim(Regex(`[a-z]`), Space)
+	expr = ExpectEof(BuildExpressionParser(mixed, term))
+	pratt = ExpectEof(BuildPrattParser(mixed, term))
+	for _, tt := range []struct {
+		s, expect string
+	}{
+		{"a + b + c", "(+ (+ a b) c)"},
+		{"a ^ b ^ c", "(^ a (^ b c))"},
+		{"a == b", "(== a b)"},
+		{"a + b == c", "operator `==` is non-associative, add parentheses in pos 7 line 1 col 7"},
+		{"a == b + c", "operator `==` is non-associative, add parentheses in pos 8 line 1 col 8"},
+		{"a + b ^ c", "operator `+` is left associative and `^` is right associative, add parentheses in pos 7 line 1 col 7"},
+		{"a ^ b + c", "operator `^` is right associative and `+` is left associative, add parentheses in pos 7 line 1 col 7"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			for _, p := range []Parser{expr, pratt} {
+				v, err := p.Parse(NewState(tt.s))
+				actual := fmt.Sprint(v)
+				if err != nil {
+					actual = err.Error()
+				}
+				if actual != tt.expect {
+					t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+				}
+			}
+		})
+	}
+}
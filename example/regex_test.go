@@ -0,0 +1,124 @@
+package example
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/states/bytestate"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+func TestRegex(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		p      Parser
+		s      State
+		expect string
+		pos    Pos
+	}{
+		{
+			name:   "char newline",
+			p:      List(charstate.Regex(`a\s+b`), charstate.Regex(`c`)),
+			s:      charstate.NewState("a\n\n  bcd"),
+			expect: "[a\n\n  b c]",
+			pos:    Pos{Idx: 7, Line: 2, Col: 4},
+		},
+		{
+			name:   "byte newline",
+			p:      List(bytestate.Regex(`a\s+b`), bytestate.Regex(`c`)),
+			s:      bytestate.NewState("a\n\n  bcd"),
+			expect: "[a\n\n  b c]",
+			pos:    Pos{Idx: 7, Line: 2, Col: 4},
+		},
+		{
+			name:   "char unicode",
+			p:      List(charstate.Regex(`\p{Han}+`), charstate.Str("，"), charstate.Regex(`\p{Han}+`), charstate.Regex(`\w+`)),
+			s:      charstate.NewState("你好，世界abc"),
+			expect: "[你好 ， 世界 abc]",
+			pos:    Pos{Idx: 8, Line: 0, Col: 8},
+		},
+		{
+			name:   "byte unicode",
+			p:      List(bytestate.Regex(`\p{Han}+`), bytestate.Regex(`\w+`)),
+			s:      bytestate.NewState("世界abc"),
+			expect: "[世界 abc]",
+			pos:    Pos{Idx: 9, Line: 0, Col: 9},
+		},
+		{
+			name:   "char groups",
+			p:      charstate.RegexGroups(`(\p{L}+)=(\d+)?(x)?`),
+			s:      charstate.NewState("键=42;"),
+			expect: "[键=42 键 42 ]",
+			pos:    Pos{Idx: 4, Line: 0, Col: 4},
+		},
+		{
+			name:   "byte groups",
+			p:      bytestate.RegexGroups(`(\w+)=(\d+)?(x)?`),
+			s:      bytestate.NewState("key=42;"),
+			expect: "[key=42 key 42 ]",
+			pos:    Pos{Idx: 6, Line: 0, Col: 6},
+		},
+		{
+			name:   "char submatch",
+			p:      charstate.RegexSubmatch(`(\d{4})-(\d{2})-(\d{2})`),
+			s:      charstate.NewState("2023-01-02T"),
+			expect: "[2023-01-02 2023 01 02]",
+			pos:    Pos{Idx: 10, Line: 0, Col: 10},
+		},
+		{
+			name:   "char named",
+			p:      charstate.RegexNamed(`(?P<key>\p{L}+)\s*=\s*(?P<val>\w+)(?:;(?P<comment>.*))?`),
+			s:      charstate.NewState("名 = v\n"),
+			expect: "map[comment: key:名 val:v]",
+			pos:    Pos{Idx: 5, Line: 0, Col: 5},
+		},
+		{
+			name:   "byte submatch",
+			p:      bytestate.RegexSubmatch(`(\d{4})-(\d{2})-(\d{2})`),
+			s:      bytestate.NewState("2023-01-02T"),
+			expect: "[2023-01-02 2023 01 02]",
+			pos:    Pos{Idx: 10, Line: 0, Col: 10},
+		},
+		{
+			name:   "byte named",
+			p:      bytestate.RegexNamed(`(?P<key>\w+)=(?P<val>\w+)(;(?P<comment>.*))?`),
+			s:      bytestate.NewState("k=v;hi"),
+			expect: "map[comment:hi key:k val:v]",
+			pos:    Pos{Idx: 6, Line: 0, Col: 6},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.p.Parse(tt.s)
+			if err != nil {
+				panic(err)
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect %q actual %q", tt.expect, actual)
+			}
+			if tt.s.Save() != tt.pos {
+				t.Errorf("expect %s actual %s", tt.pos, tt.s.Save())
+			}
+		})
+	}
+}
+
+// Regex 不复制剩余输入, 耗时与输入长度线性相关
+func BenchmarkRegexLargeInput(b *testing.B) {
+	src := strings.Repeat("hello 世界 42\n", 1<<16)
+	p := charstate.Regex(`\S+\s*`)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := charstate.NewState(src)
+		for {
+			if _, err := p.Parse(s); err != nil {
+				break
+			}
+		}
+		if _, err := Eof.Parse(s); err != nil {
+			panic(err)
+		}
+	}
+}
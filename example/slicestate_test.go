@@ -0,0 +1,62 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/states/slicestate"
+)
+
+type event string
+
+type node struct {
+	kind      string
+	line, col int
+}
+
+func (n *node) String() string { return n.kind }
+
+func TestSliceState(t *testing.T) {
+	t.Run("events", func(t *testing.T) {
+		session := List(Equal(event("login")), Many(OneOf(event("view"), event("click"))), Equal(event("logout")))
+		s := NewState([]interface{}{event("login"), event("view"), event("click"), event("logout")})
+		v, err := ExpectEof(session).Parse(s)
+		if err != nil {
+			panic(err)
+		}
+		if fmt.Sprint(v) != "[login [view click] logout]" {
+			t.Errorf("expect [login [view click] logout] actual %v", v)
+		}
+
+		_, err = ExpectEof(session).Parse(NewState([]interface{}{event("login"), event("view"), event("buy")}))
+		expect := "expect `logout` actual `buy` in pos 3 line 1 col 3"
+		if err == nil || err.Error() != expect {
+			t.Errorf("expect \"%s\" actual \"%v\"", expect, err)
+		}
+	})
+
+	t.Run("nodes", func(t *testing.T) {
+		// 把 [x, =, y, +, 1] 这样的节点序列重写为赋值
+		kind := func(k string) Parser {
+			return ItemSatisfy(func(v interface{}) bool { return v.(*node).kind == k }, k)
+		}
+		assign := List(TypeOf((*node)(nil)), kind("="), SepBy1(NoneOf(&node{kind: ";"}), Return(nil)))
+		nodes := []interface{}{&node{"x", 1, 1}, &node{"=", 1, 3}, &node{"y", 1, 5}, &node{"+", 2, 1}, &node{"1", 2, 3}}
+		posOf := func(v interface{}) (int, int) { return v.(*node).line - 1, v.(*node).col - 1 }
+
+		v, err := ExpectEof(assign).Parse(NewStateWithPos(nodes, posOf))
+		if err != nil {
+			panic(err)
+		}
+		if fmt.Sprint(v) != "[x = [y + 1]]" {
+			t.Errorf("expect [x = [y + 1]] actual %v", v)
+		}
+
+		_, err = List(kind("x"), kind(":=")).Parse(NewStateWithPos(nodes, posOf))
+		expect := "expect `:=` actual `=` in pos 2 line 1 col 3"
+		if err == nil || err.Error() != expect {
+			t.Errorf("expect \"%s\" actual \"%v\"", expect, err)
+		}
+	})
+}
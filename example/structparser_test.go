@@ -0,0 +1,118 @@
+package example
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/states/charstate"
+	"github.com/goghcrow/parsec/structparser"
+)
+
+type Config struct {
+	Sections []*Section `parsec:"@@*"`
+}
+
+type Section struct {
+	Name    string   `parsec:"'[' @Ident ']'"`
+	Entries []*Entry `parsec:"@@*"`
+}
+
+type Entry struct {
+	Key   string `parsec:"@Ident ( @'.' @Ident )* '='"`
+	Value *Value `parsec:"@@"`
+}
+
+type Value struct {
+	Str  *string  `parsec:"  @String"`
+	Num  *float64 `parsec:"| @Number"`
+	Bool bool     `parsec:"| @'true'"`
+	Neg  bool     `parsec:"| @'-'?"`
+	Int  int      `parsec:"    @Int"`
+	List []*Value `parsec:"| '[' ( @@ ( ',' @@ )* )? ']'"`
+}
+
+func TestStructParser(t *testing.T) {
+	tok := func(p Parser) Parser { return Trim(p, Space) }
+	g := structparser.NewGrammar(map[string]Parser{
+		"Ident": tok(Regex(`[a-z]+`)),
+		"String": tok(Regex(`"[^"]*"`)).Map(func(v interface{}) interface{} {
+			s, _ := strconv.Unquote(v.(string))
+			return s
+		}),
+		"Number": tok(Regex(`\d+\.\d+`)),
+		"Int":    tok(Regex(`\d+`)),
+	}, func(s string) Parser { return tok(Str(s)) })
+	p := ExpectEof(g.Build(&Config{}))
+
+	src := `
+[server]
+host = "localhost"
+port = 8080
+ratio = 0.5
+log.level.debug = true
+[client]
+retry = - 3
+hosts = ["a", 1.5, []]
+`
+	v, err := p.Parse(NewState(src))
+	if err != nil {
+		panic(err)
+	}
+	actual, _ := json.Marshal(v)
+	expect := `{"Sections":[` +
+		`{"Name":"server","Entries":[` +
+		`{"Key":"host","Value":{"Str":"localhost","Num":null,"Bool":false,"Neg":false,"Int":0,"List":null}},` +
+		`{"Key":"port","Value":{"Str":null,"Num":null,"Bool":false,"Neg":false,"Int":8080,"List":null}},` +
+		`{"Key":"ratio","Value":{"Str":null,"Num":0.5,"Bool":false,"Neg":false,"Int":0,"List":null}},` +
+		`{"Key":"log.level.debug","Value":{"Str":null,"Num":null,"Bool":true,"Neg":false,"Int":0,"List":null}}]},` +
+		`{"Name":"client","Entries":[` +
+		`{"Key":"retry","Value":{"Str":null,"Num":null,"Bool":false,"Neg":true,"Int":3,"List":null}},` +
+		`{"Key":"hosts","Value":{"Str":null,"Num":null,"Bool":false,"Neg":false,"Int":0,"List":[` +
+		`{"Str":"a","Num":null,"Bool":false,"Neg":false,"Int":0,"List":null},` +
+		`{"Str":null,"Num":1.5,"Bool":false,"Neg":false,"Int":0,"List":null},` +
+		`{"Str":null,"Num":null,"Bool":false,"Neg":false,"Int":0,"List":null}]}}]}]}`
+	if string(actual) != expect {
+		t.Errorf("expect\n%s\nactual\n%s", expect, actual)
+	}
+
+	_, err = p.Parse(NewState("[a]\nx = 99999999999999999999"))
+	expectErr := "parse aborted: Value.Int: strconv.ParseInt: parsing \"99999999999999999999\": value out of range in pos 9 line 2 col 5"
+	if err == nil || err.Error() != expectErr {
+		t.Errorf("expect \"%s\" actual \"%v\"", expectErr, err)
+	}
+	// 中止之后的解析都会失败, 即使外层忽略了这个错误
+	ignore := NewParser(func(s State) (interface{}, error) { _, _ = p.Parse(s); return nil, nil })
+	_, err = Run(NewContext(nil), List(ignore, Return(1)), NewState("[a]\nx = 99999999999999999999"))
+	if err == nil || err.Error() != expectErr {
+		t.Errorf("expect \"%s\" actual \"%v\"", expectErr, err)
+	}
+
+	type undefinedTerm struct {
+		X string `parsec:"@Undefined"`
+	}
+	type unclosedGroup struct {
+		X string `parsec:"( @Ident"`
+	}
+	type selfNotStruct struct {
+		X string `parsec:"@@"`
+	}
+	for _, tt := range []struct {
+		proto interface{}
+		panic string
+	}{
+		{&undefinedTerm{}, "structparser: undefinedTerm: undefined terminal `Undefined`"},
+		{&unclosedGroup{}, "structparser: unclosedGroup: expect `)`"},
+		{&selfNotStruct{}, "structparser: selfNotStruct.X: @@ requires struct field"},
+	} {
+		t.Run(tt.panic, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != tt.panic {
+					t.Errorf("expect panic \"%s\" actual \"%v\"", tt.panic, r)
+				}
+			}()
+			g.Build(tt.proto)
+		})
+	}
+}
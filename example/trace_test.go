@@ -0,0 +1,75 @@
+package example
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goghcrow/parsec/peg"
+	"github.com/goghcrow/parsec/states/charstate"
+	"github.com/goghcrow/parsec/trace"
+)
+
+func TestTraceExport(t *testing.T) {
+	ps := peg.MustLoad(`
+S <- A "+" A / A
+A <- [0-9]
+`).MustBuild(peg.Options{})
+
+	tr, v, err := trace.Record(ps.Start, charstate.NewState("1"))
+	if err != nil || v != "1" {
+		t.Fatalf("unexpected %v %v", v, err)
+	}
+
+	var dot strings.Builder
+	if err := tr.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	expectDOT := `digraph parse {
+  node [shape=box, style=filled, fontname="monospace"];
+  n0 [label="#root\n0-1", fillcolor="#c8e6c9"];
+  n1 [label="S\n0-1", fillcolor="#c8e6c9"];
+  n2 [label="A\n0-1", fillcolor="#e0e0e0"];
+  n1 -> n2;
+  n3 [label="A\n0-1", fillcolor="#c8e6c9"];
+  n1 -> n3;
+  n0 -> n1;
+}
+`
+	if dot.String() != expectDOT {
+		t.Errorf("expect\n%s\nactual\n%s", expectDOT, dot.String())
+	}
+
+	var js strings.Builder
+	if err := tr.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"source": "1"`, `"rule": "S"`, `"status": "backtracked"`, `"to": 1`} {
+		if !strings.Contains(js.String(), s) {
+			t.Errorf("expect %s in\n%s", s, js.String())
+		}
+	}
+
+	var html strings.Builder
+	if err := tr.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`const src = Array.from("1");`,
+		`<details open class="backtracked" data-from="0" data-to="1"><summary class="leaf">A 0-1</summary></details>`,
+	} {
+		if !strings.Contains(html.String(), s) {
+			t.Errorf("expect %s in\n%s", s, html.String())
+		}
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		tr, _, err := trace.Record(ps.Start, charstate.NewState("x"))
+		if err == nil {
+			t.Fatal("expect error")
+		}
+		s := tr.Root.Children[0]
+		if s.Rule != "S" || s.Status != trace.StatusFail || s.Err != "expect pattern '[0-9]' in pos 1 line 1 col 1" {
+			t.Errorf("unexpected %s %s", s.Label(), s.Status)
+		}
+	})
+}
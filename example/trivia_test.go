@@ -0,0 +1,92 @@
+package example
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goghcrow/lexer"
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/states/tokstate"
+)
+
+func TestTrivia(t *testing.T) {
+	const (
+		Ident lexer.TokenKind = iota + 1
+		Eq
+		Int
+		Comment
+		Space
+	)
+	lex := lexer.BuildLexer(func(lex *lexer.Lexicon) {
+		lex.Str(Eq, "=")
+		lex.Regex(Int, `\d+`)
+		lex.Regex(Ident, `[a-z]+`)
+		lex.Regex(Comment, `//[^\n]*`)
+		lex.Regex(Space, `\s+`)
+	})
+	isTrivia := func(tok *lexer.Token) bool { return tok.TokenKind == Comment || tok.TokenKind == Space }
+	show := func(toks []*lexer.Token) string {
+		xs := make([]string, len(toks))
+		for i, tok := range toks {
+			xs[i] = fmt.Sprintf("%q", tok.Lexeme)
+		}
+		return "[" + strings.Join(xs, " ") + "]"
+	}
+
+	src := "// head\na = 1 // one\n\n// two\nb=2  \n// tail\n"
+	s := NewStateWithTrivia(lex.MustLex(src), isTrivia).(*TokState)
+	stmt := WithSpan(List(Tok(Ident, "ident"), Str("="), Tok(Int, "int")))
+	v, err := ExpectEof(Many(stmt)).Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	stmts := v.([]interface{})
+
+	if s.Source() != src {
+		t.Errorf("expect %q actual %q", src, s.Source())
+	}
+
+	var texts, full []string
+	for _, x := range stmts {
+		sp := x.(Spanned).Span
+		texts = append(texts, s.Text(sp.Start, sp.End))
+		full = append(full, s.FullText(sp.Start, sp.End))
+	}
+	if fmt.Sprintf("%q", texts) != `["a = 1" "b=2"]` {
+		t.Errorf("expect [\"a = 1\" \"b=2\"] actual %q", texts)
+	}
+	eof, _ := s.Trivia(len(stmts) * 3)
+	if strings.Join(full, "")+lexemes(eof) != src {
+		t.Errorf("expect %q actual %q", src, strings.Join(full, ""))
+	}
+
+	for _, tt := range []struct {
+		i                 int
+		leading, trailing string
+	}{
+		{0, `["// head" "\n"]`, `[" "]`},
+		{2, `[]`, `[" " "// one" "\n\n"]`},
+		{3, `["// two" "\n"]`, `[]`},
+		{5, `[]`, `["  \n"]`},
+		{6, `["// tail" "\n"]`, `[]`},
+	} {
+		leading, trailing := s.Trivia(tt.i)
+		if show(leading) != tt.leading || show(trailing) != tt.trailing {
+			t.Errorf("%d: expect %s %s actual %s %s", tt.i, tt.leading, tt.trailing, show(leading), show(trailing))
+		}
+	}
+
+	one := stmts[0].(Spanned).Value.([]interface{})[2].(*lexer.Token)
+	if leading, trailing := s.TriviaOf(one); len(leading) != 0 || len(trailing) != 3 {
+		t.Errorf("unexpected trivia of `1`: %s %s", show(leading), show(trailing))
+	}
+}
+
+func lexemes(toks []*lexer.Token) string {
+	var b strings.Builder
+	for _, tok := range toks {
+		b.WriteString(tok.Lexeme)
+	}
+	return b.String()
+}
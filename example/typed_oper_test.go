@@ -0,0 +1,99 @@
+package example
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/goghcrow/parsec"
+	. "github.com/goghcrow/parsec/exprparser"
+	. "github.com/goghcrow/parsec/states/charstate"
+)
+
+type binExpr struct {
+	op   string
+	span Span
+	l, r interface{}
+}
+
+func (b *binExpr) String() string {
+	return fmt.Sprintf("(%s@%d %v %v)", b.op, b.span.Start.Idx, b.l, b.r)
+}
+
+type unaryExpr struct {
+	op   string
+	span Span
+	x    interface{}
+}
+
+func (u *unaryExpr) String() string { return fmt.Sprintf("(%s@%d %v)", u.op, u.span.Start.Idx, u.x) }
+
+func TestTypedOperator(t *testing.T) {
+	sym := func(s string) Parser { return Left(Str(s), Spaces) }
+	bin := func(op Op, l, r interface{}) interface{} { return &binExpr{op.Value.(string), op.Span, l, r} }
+	un := func(op Op, x interface{}) interface{} { return &unaryExpr{op.Value.(string), op.Span, x} }
+
+	table := OperatorTable{
+		{PrefixOp(sym("-"), un), PostfixOp(sym("!"), un)},
+		{InfixR(sym("^"), bin)},
+		{InfixL(sym("*"), bin)},
+		{InfixL(sym("+"), bin), InfixL(sym("-"), bin)},
+		{InfixN(sym("=="), bin)},
+	}
+	expr := ExpectEof(BuildExpressionParser(table, Left(Regex(`\d+`), Spaces)))
+
+	for _, tt := range []struct {
+		s      string
+		expect string
+	}{
+		{"1 + 2 * 3", "(+@2 1 (*@6 2 3))"},
+		{"2 ^ 3 ^ 4", "(^@2 2 (^@6 3 4))"},
+		{"-1! == 2 - 3", "(==@4 (!@2 (-@0 1)) (-@9 2 3))"},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			v, err := expr.Parse(NewState(tt.s))
+			if err != nil {
+				panic(err)
+			}
+			actual := fmt.Sprint(v)
+			if actual != tt.expect {
+				t.Errorf("expect \"%s\" actual \"%s\"", tt.expect, actual)
+			}
+		})
+	}
+
+	// 操作符的 span 不包含 symbol 之前的部分
+	v, _ := expr.Parse(NewState("10 +  2"))
+	span := v.(*binExpr).span
+	if span.Start.Idx != 3 || span.End.Idx != 6 {
+		t.Errorf("expect span 3-6 actual %d-%d", span.Start.Idx, span.End.Idx)
+	}
+}
+
+func TestOperatorValidation(t *testing.T) {
+	expectPanic := func(expect string, f func()) {
+		defer func() {
+			actual := fmt.Sprint(recover())
+			if actual != expect {
+				t.Errorf("expect panic \"%s\" actual \"%s\"", expect, actual)
+			}
+		}()
+		f()
+	}
+
+	expectPanic("invalid operator [0][1]: invalid assoc 3", func() {
+		BuildExpressionParser(OperatorTable{{
+			NewPrefix(Str("-")),
+			{OperKind: Infix, Assoc: 3, Parser: Str("+")},
+		}}, Regex(`\d+`))
+	})
+	expectPanic("invalid operator [1][0]: nil parser", func() {
+		BuildPrattParser(OperatorTable{{NewPrefix(Str("-"))}, {NewInfixL(nil)}}, Regex(`\d+`))
+	})
+	expectPanic("InfixL: symbol and build must not be nil", func() {
+		InfixL(Str("+"), nil)
+	})
+	expectPanic("infix operator must return func(l, r interface{}) interface{}, actual string", func() {
+		p := BuildExpressionParser(OperatorTable{{NewInfixL(Str("+"))}}, Regex(`\d+`))
+		_, _ = p.Parse(NewState("1+2"))
+	})
+}
@@ -7,8 +7,12 @@ import . "github.com/goghcrow/parsec"
 // 注意:
 // 1. 相同优先级的前缀后缀操作符只能出现一次 (e.g. 如果 - 是代表负数, 则不允许 --2)
 // 2. 相同优先级的前缀后缀操作符优先左关联 (e.g. 如果 ++ 是后缀自增, 则 -2++ 等 -1, 而不是 -3)
+// 3. 匹配到操作符之后不再回溯, 缺少操作数或者非结合操作符连用会报错
 // 具体实例参见 example/buildexpr_test.go
 func BuildExpressionParser(opers OperatorTable, term Parser) Parser {
+	if err := opers.Validate(); err != nil {
+		panic(err)
+	}
 	p := term
 	for _, ops := range opers {
 		p = makeParser(p, ops)
@@ -20,98 +24,116 @@ func BuildExpressionParser(opers OperatorTable, term Parser) Parser {
 func makeParser(term Parser, ops []Operator) Parser {
 	rassoc, lassoc, nassoc, prefix, postfix := groupByOpers(ops)
 
-	rassocOp := Choice(rassoc...)
-	lassocOp := Choice(lassoc...)
-	nassocOp := Choice(nassoc...)
-	prefixOp := Label(Choice(prefix...), "") // 前后缀可选, 取消错误信息
-	postfixOp := Label(Choice(postfix...), "")
+	rassocOp := opGroup{Choice(rassoc...), AssocRight}
+	lassocOp := opGroup{Choice(lassoc...), AssocLeft}
+	nassocOp := opGroup{Choice(nassoc...), AssocNone}
+	prefixOp := Choice(prefix...)
+	postfixOp := Choice(postfix...)
 
-	ambiguous := func(assoc Assoc, op Parser) Parser {
-		return Try(Bind(op, func(_ interface{}) Parser {
-			return Fail("ambiguous use of a %s associative operator", assoc)
-		}))
+	// 📢: 前缀优先于后缀
+	termP := func(s State) (interface{}, error) {
+		if op, ok := matchOp(s, prefixOp); ok {
+			x, err := operand(s, term, op)
+			if err != nil {
+				return nil, err
+			}
+			return applyPostfix(s, postfixOp, unaryFn(op.Value)(x))
+		}
+		x, err := term.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return applyPostfix(s, postfixOp, x)
+	}
+	operandP := func(s State, op matched) (interface{}, error) {
+		return operand(s, NewParser(termP), op)
 	}
 
-	ambiguousRight := ambiguous(AssocRight, rassocOp)
-	ambiguousLeft := ambiguous(AssocLeft, lassocOp)
-	ambiguousNon := ambiguous(AssocNone, nassocOp)
-
-	id := func(a interface{}) interface{} { return a }
-	postfixP := Either(postfixOp, Return(id))
-	prefixP := Either(prefixOp, Return(id))
-
-	termP := Bind(prefixP, func(pre interface{}) Parser {
-		return Bind(term, func(x interface{}) Parser {
-			return Bind(postfixP, func(post interface{}) Parser {
-				postFn := post.(func(interface{}) interface{})
-				preFn := pre.(func(interface{}) interface{})
-				// 📢: 前缀优先于后缀
-				return Return(postFn(preFn(x)))
-			})
-		})
-	})
+	// 同一层出现了其他结合性的操作符
+	ambiguous := func(s State, op matched, others ...opGroup) error {
+		for _, other := range others {
+			if op2, ok := matchInfix(s, other); ok {
+				s.Restore(op2.Start)
+				return ambiguousErr(s, op, op2)
+			}
+		}
+		return nil
+	}
 
 	// 这里逻辑 跟 Chainr 一致, 只多了歧义处理
-	var rassocP, rassocP1 func(x interface{}) Parser
-	rassocP = func(x interface{}) Parser {
-		return Alt(
-			Bind(rassocOp, func(f interface{}) Parser {
-				return Bind(Bind(termP, rassocP1), func(y interface{}) Parser {
-					fn := f.(func(x, y interface{}) interface{})
-					return Return(fn(x, y))
-				})
-			}),
-			ambiguousLeft,
-			ambiguousNon,
-		)
-	}
-	rassocP1 = func(x interface{}) Parser {
-		return Either(rassocP(x), Return(x))
+	var rassocP func(s State, x interface{}, op matched) (interface{}, error)
+	rassocP = func(s State, x interface{}, op matched) (interface{}, error) {
+		y, err := operandP(s, op)
+		if err != nil {
+			return nil, err
+		}
+		if op2, ok := matchInfix(s, rassocOp); ok {
+			y, err = rassocP(s, y, op2)
+			if err != nil {
+				return nil, err
+			}
+		} else if err = ambiguous(s, op, lassocOp, nassocOp); err != nil {
+			return nil, err
+		}
+		return binaryFn(op.Value)(x, y), nil
 	}
 
 	// 这里逻辑 跟 Chainl.chainl1Rest 一致, 只多了歧义处理
-	var lassocP, lassocP1 func(x interface{}) Parser
-	lassocP = func(x interface{}) Parser {
-		return Alt(
-			Bind(lassocOp, func(f interface{}) Parser {
-				return Bind(termP, func(y interface{}) Parser {
-					fn := f.(func(x, y interface{}) interface{})
-					return lassocP1(fn(x, y))
-				})
-			}),
-			ambiguousRight,
-			ambiguousNon,
-		)
-	}
-	lassocP1 = func(x interface{}) Parser {
-		return Either(lassocP(x), Return(x))
+	lassocP := func(s State, x interface{}, op matched) (interface{}, error) {
+		for {
+			y, err := operandP(s, op)
+			if err != nil {
+				return nil, err
+			}
+			x = binaryFn(op.Value)(x, y)
+			op2, ok := matchInfix(s, lassocOp)
+			if !ok {
+				if err = ambiguous(s, op, rassocOp, nassocOp); err != nil {
+					return nil, err
+				}
+				return x, nil
+			}
+			op = op2
+		}
 	}
 
-	nassocP := func(x interface{}) Parser {
-		return Bind(nassocOp, func(f interface{}) Parser {
-			return Bind(termP, func(y interface{}) Parser {
-				// 与左结合的区别是, 不继续匹配
-				fn := f.(func(x, y interface{}) interface{})
-				return Alt(
-					ambiguousRight,
-					ambiguousLeft,
-					ambiguousNon,
-					Return(fn(x, y)),
-				)
-			})
-		})
+	// 与左结合的区别是, 不继续匹配
+	nassocP := func(s State, x interface{}, op matched) (interface{}, error) {
+		y, err := operandP(s, op)
+		if err != nil {
+			return nil, err
+		}
+		if err = ambiguous(s, op, rassocOp, lassocOp, nassocOp); err != nil {
+			return nil, err
+		}
+		return binaryFn(op.Value)(x, y), nil
 	}
 
-	return Bind(termP, func(x interface{}) Parser {
-		return Label(Alt(
-			rassocP(x),
-			lassocP(x),
-			nassocP(x),
-			Return(x),
-		), "expect `operator`")
+	return NewParser(func(s State) (interface{}, error) {
+		x, err := termP(s)
+		if err != nil {
+			return nil, err
+		}
+		if op, ok := matchInfix(s, rassocOp); ok {
+			return rassocP(s, x, op)
+		}
+		if op, ok := matchInfix(s, lassocOp); ok {
+			return lassocP(s, x, op)
+		}
+		if op, ok := matchInfix(s, nassocOp); ok {
+			return nassocP(s, x, op)
+		}
+		return x, nil
 	})
 }
 
+func applyPostfix(s State, postfixOp Parser, x interface{}) (interface{}, error) {
+	if op, ok := matchOp(s, postfixOp); ok {
+		return unaryFn(op.Value)(x), nil
+	}
+	return x, nil
+}
+
 //goland:noinspection SpellCheckingInspection
 func groupByOpers(ops []Operator) (rassoc, lassoc, nassoc, prefix, postfix []Parser) {
 	for _, op := range ops {
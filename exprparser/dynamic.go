@@ -0,0 +1,61 @@
+package exprparser
+
+import . "github.com/goghcrow/parsec"
+
+// NewOperatorRegistry 运行时可变的操作符表, 通常放在 user state 中 (State.Put)
+// 使得输入中靠前的操作符声明 (e.g. infixl 6 <+>) 影响之后的表达式解析
+func NewOperatorRegistry(ops ...Operator) *OperatorRegistry {
+	return &OperatorRegistry{
+		ops:   append([]Operator{}, ops...),
+		built: map[*dynamicParser]builtParser{},
+	}
+}
+
+// OperatorRegistry 零值为空的操作符表, 可以直接使用
+type OperatorRegistry struct {
+	ops   []Operator
+	ver   int
+	built map[*dynamicParser]builtParser
+}
+
+type builtParser struct {
+	ver int
+	Parser
+}
+
+// Add 添加操作符, Prec 必须设置
+// 📢: 声明是副作用, 回溯不会撤销, 声明语法应避免出现在会回溯的分支中
+// 同一优先级内按添加顺序尝试, 有公共前缀的操作符需要先添加长的
+func (r *OperatorRegistry) Add(op Operator) {
+	r.ops = append(r.ops, op)
+	r.ver++
+}
+
+func (r *OperatorRegistry) Table() OperatorTable { return BuildOperatorTable(r.ops) }
+
+// BuildDynamicExpressionParser 每次解析时通过 registry 取当前的操作符表构建表达式 parser
+// 操作符表没有变化时复用上次构建的 parser
+// e.g. BuildDynamicExpressionParser(func(s State) *OperatorRegistry { return s.Get().(*OperatorRegistry) }, term)
+func BuildDynamicExpressionParser(registry func(State) *OperatorRegistry, term Parser) Parser {
+	return &dynamicParser{registry: registry, term: term}
+}
+
+type dynamicParser struct {
+	registry func(State) *OperatorRegistry
+	term     Parser
+}
+
+func (d *dynamicParser) Parse(s State) (interface{}, error) {
+	r := d.registry(s)
+	b, ok := r.built[d]
+	if !ok || b.ver != r.ver {
+		if r.built == nil {
+			r.built = map[*dynamicParser]builtParser{}
+		}
+		b = builtParser{r.ver, BuildExpressionParser(r.Table(), d.term)}
+		r.built[d] = b
+	}
+	return b.Parse(s)
+}
+func (d *dynamicParser) Map(f func(v interface{}) interface{}) Parser { return Map(d, f) }
+func (d *dynamicParser) FlatMap(f func(v interface{}) Parser) Parser  { return FlatMap(d, f) }
@@ -46,3 +46,64 @@ func NewInfixN(p Parser) Operator {
 		Parser:   p,
 	}
 }
+
+// ----------------------------------------------------------------
+// 类型安全的构造函数
+// symbol 只负责匹配操作符 (返回值任意), build 负责构造 ast, 不需要返回特定签名的函数
+// Prefix Postfix 已用作 OperKind, 所以前后缀命名为 PrefixOp PostfixOp
+// ----------------------------------------------------------------
+
+// Op 匹配到的操作符
+type Op struct {
+	Value interface{} // symbol 的返回值
+	Span              // 操作符的位置
+}
+
+func PrefixOp(symbol Parser, build func(op Op, x interface{}) interface{}) Operator {
+	return NewPrefix(unary("PrefixOp", symbol, build))
+}
+
+func PostfixOp(symbol Parser, build func(op Op, x interface{}) interface{}) Operator {
+	return NewPostfix(unary("PostfixOp", symbol, build))
+}
+
+func InfixL(symbol Parser, build func(op Op, l, r interface{}) interface{}) Operator {
+	return NewInfixL(binary("InfixL", symbol, build))
+}
+
+func InfixR(symbol Parser, build func(op Op, l, r interface{}) interface{}) Operator {
+	return NewInfixR(binary("InfixR", symbol, build))
+}
+
+func InfixN(symbol Parser, build func(op Op, l, r interface{}) interface{}) Operator {
+	return NewInfixN(binary("InfixN", symbol, build))
+}
+
+func unary(name string, symbol Parser, build func(op Op, x interface{}) interface{}) Parser {
+	if symbol == nil || build == nil {
+		panic(name + ": symbol and build must not be nil")
+	}
+	return spanned(symbol, func(op Op) interface{} {
+		return func(x interface{}) interface{} { return build(op, x) }
+	})
+}
+
+func binary(name string, symbol Parser, build func(op Op, l, r interface{}) interface{}) Parser {
+	if symbol == nil || build == nil {
+		panic(name + ": symbol and build must not be nil")
+	}
+	return spanned(symbol, func(op Op) interface{} {
+		return func(l, r interface{}) interface{} { return build(op, l, r) }
+	})
+}
+
+func spanned(symbol Parser, f func(op Op) interface{}) Parser {
+	return NewParser(func(s State) (interface{}, error) {
+		start := s.Save()
+		v, err := symbol.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return f(Op{Value: v, Span: Span{Start: start, End: s.Save()}}), nil
+	})
+}
@@ -1,6 +1,7 @@
 package exprparser
 
 import (
+	"fmt"
 	"sort"
 
 	. "github.com/goghcrow/parsec"
@@ -42,7 +43,41 @@ type Operator struct {
 // 📢: 每一层的优先级相同(结合性可能不同), 层之间优先级降序
 type OperatorTable [][]Operator
 
+func (op Operator) validate() error {
+	if op.Parser == nil {
+		return fmt.Errorf("nil parser")
+	}
+	switch op.OperKind {
+	case Prefix, Postfix:
+		return nil
+	case Infix:
+		if op.Assoc < AssocNone || op.Assoc > AssocRight {
+			return fmt.Errorf("invalid assoc %d", op.Assoc)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid operator kind %d", op.OperKind)
+	}
+}
+
+// Validate 检查操作符表, BuildOperatorTable BuildExpressionParser BuildPrattParser 构建时会检查
+func (t OperatorTable) Validate() error {
+	for i, ops := range t {
+		for j, op := range ops {
+			if err := op.validate(); err != nil {
+				return fmt.Errorf("invalid operator [%d][%d]: %s", i, j, err)
+			}
+		}
+	}
+	return nil
+}
+
 func BuildOperatorTable(ops []Operator) OperatorTable {
+	for i, op := range ops {
+		if err := op.validate(); err != nil {
+			panic(fmt.Sprintf("invalid operator [%d]: %s", i, err))
+		}
+	}
 	group := map[float32][]Operator{}
 	var precs []float32
 	for _, op := range ops {
@@ -59,3 +94,21 @@ func BuildOperatorTable(ops []Operator) OperatorTable {
 	}
 	return tbl
 }
+
+// Prefix、PostFix 的 Parser 返回值的检查, 给出比类型断言更明确的错误
+func unaryFn(f interface{}) func(interface{}) interface{} {
+	fn, ok := f.(func(interface{}) interface{})
+	if !ok {
+		panic(fmt.Sprintf("prefix/postfix operator must return func(interface{}) interface{}, actual %T", f))
+	}
+	return fn
+}
+
+// Infix 的 Parser 返回值的检查
+func binaryFn(f interface{}) func(l, r interface{}) interface{} {
+	fn, ok := f.(func(l, r interface{}) interface{})
+	if !ok {
+		panic(fmt.Sprintf("infix operator must return func(l, r interface{}) interface{}, actual %T", f))
+	}
+	return fn
+}
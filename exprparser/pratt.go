@@ -0,0 +1,198 @@
+package exprparser
+
+import . "github.com/goghcrow/parsec"
+
+// Pratt parser (top down operator precedence)
+// 与 BuildExpressionParser 相比:
+// 1. 不按优先级分层嵌套, 每个操作数只解析一次
+// 2. 支持重复的前后缀操作符 (e.g. --x, x!!)
+// 3. 可以通过 Nud / Led 注册 mixfix 操作符 (e.g. a ? b : c, a[b], f(x))
+//
+// bp (binding power) 越大结合越紧密, Expr(bp) 只消费左 bp 大于 bp 的操作符
+// 操作符按注册顺序尝试 (自动 Try), 有公共前缀的操作符需要先注册长的 (e.g. ++ 先于 +)
+func NewPratt(term Parser) *Pratt { return &Pratt{term: term} }
+
+// BuildPrattParser 从操作符表构建 Pratt parser, 层之间优先级降序, 优先级与结合性同 BuildExpressionParser
+// 同一层的非结合操作符连用, 或者左结合与右结合操作符混用, 同样报歧义 (e.g. a + b ^ c)
+// 区别是允许重复的前后缀操作符
+func BuildPrattParser(opers OperatorTable, term Parser) *Pratt {
+	if err := opers.Validate(); err != nil {
+		panic(err)
+	}
+	pp := NewPratt(term)
+	for i, ops := range opers {
+		bp := len(opers) - i
+		for _, op := range ops {
+			pp.Operator(bp, op)
+		}
+	}
+	return pp
+}
+
+type Pratt struct {
+	term Parser
+	nuds []nud
+	leds []led
+}
+
+// nud (null denotation) 出现在表达式开头的操作符
+type nud struct {
+	op Parser
+	fn func(op interface{}) Parser
+}
+
+// led (left denotation) 出现在左操作数之后的操作符
+type led struct {
+	op    Parser
+	bp    int
+	infix bool
+	Assoc
+	fn func(op, lhs interface{}) Parser // 中缀操作符为 nil, 由 parseInfix 处理
+}
+
+// infixOp 已经匹配的中缀操作符, 用于检查相同 bp 的结合性
+type infixOp struct {
+	matched
+	bp int
+}
+
+// Nud 注册前缀位置的操作符, op 匹配后 f 返回的 parser 负责解析剩余部分
+func (pp *Pratt) Nud(op Parser, f func(op interface{}) Parser) *Pratt {
+	pp.nuds = append(pp.nuds, nud{op: op, fn: f})
+	return pp
+}
+
+// Led 注册中缀位置的操作符, 左 bp 为 bp, op 匹配后 f 返回的 parser 负责解析剩余部分
+// e.g. 三元 a ? b : c
+// pp.Led(bp, Str("?"), func(_, c interface{}) Parser { return List(pp.Expr(0), Str(":"), pp.Expr(bp-1)) })
+func (pp *Pratt) Led(bp int, op Parser, f func(op, lhs interface{}) Parser) *Pratt {
+	pp.leds = append(pp.leds, led{op: op, bp: bp, fn: f})
+	return pp
+}
+
+// Prefix op 必须返回 func(interface{}) interface{}
+func (pp *Pratt) Prefix(bp int, op Parser) *Pratt {
+	rhs := pp.Expr(bp)
+	return pp.Nud(op, func(f interface{}) Parser {
+		return Map(rhs, func(x interface{}) interface{} {
+			return unaryFn(f)(x)
+		})
+	})
+}
+
+// Postfix op 必须返回 func(interface{}) interface{}
+func (pp *Pratt) Postfix(bp int, op Parser) *Pratt {
+	return pp.Led(bp, op, func(f, lhs interface{}) Parser {
+		return Return(unaryFn(f)(lhs))
+	})
+}
+
+// InfixL op 必须返回 func(l, r interface{}) interface{}
+func (pp *Pratt) InfixL(bp int, op Parser) *Pratt { return pp.infix(bp, AssocLeft, op) }
+
+// InfixR op 必须返回 func(l, r interface{}) interface{}
+func (pp *Pratt) InfixR(bp int, op Parser) *Pratt { return pp.infix(bp, AssocRight, op) }
+
+// InfixN op 必须返回 func(l, r interface{}) interface{}
+// 相同 bp 的中缀操作符不能在其前后连用, e.g. a == b == c, a + b == c
+func (pp *Pratt) InfixN(bp int, op Parser) *Pratt { return pp.infix(bp, AssocNone, op) }
+
+// Operator 以 bp 注册 BuildExpressionParser 使用的 Operator
+func (pp *Pratt) Operator(bp int, op Operator) *Pratt {
+	if err := op.validate(); err != nil {
+		panic(err)
+	}
+	switch op.OperKind {
+	case Prefix:
+		return pp.Prefix(bp, op.Parser)
+	case Postfix:
+		return pp.Postfix(bp, op.Parser)
+	case Infix:
+		return pp.infix(bp, op.Assoc, op.Parser)
+	default:
+		panic("unreached")
+	}
+}
+
+func (pp *Pratt) infix(bp int, assoc Assoc, op Parser) *Pratt {
+	pp.leds = append(pp.leds, led{op: op, bp: bp, infix: true, Assoc: assoc})
+	return pp
+}
+
+// Expr 解析只包含左 bp 大于 bp 的操作符的表达式
+func (pp *Pratt) Expr(bp int) Parser {
+	return NewParser(func(s State) (interface{}, error) { return pp.parse(s, bp, nil) })
+}
+
+func (pp *Pratt) Parse(s State) (interface{}, error)           { return pp.parse(s, 0, nil) }
+func (pp *Pratt) Map(f func(v interface{}) interface{}) Parser { return Map(pp, f) }
+func (pp *Pratt) FlatMap(f func(v interface{}) Parser) Parser  { return FlatMap(pp, f) }
+
+// last 为同一层上一个中缀操作符, 相同 bp 的中缀操作符只能是同一种结合性的左结合或右结合, 否则报歧义, 同 BuildExpressionParser
+func (pp *Pratt) parse(s State, minBp int, last *infixOp) (interface{}, error) {
+	lhs, err := pp.parseNud(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		l, op, ok := pp.matchLed(s)
+		if !ok {
+			return lhs, nil
+		}
+		if l.bp <= minBp {
+			s.Restore(op.Start)
+			return lhs, nil
+		}
+		if !l.infix {
+			lhs, err = operand(s, l.fn(op.Value, lhs), op)
+			if err != nil {
+				return nil, err
+			}
+			last = nil
+			continue
+		}
+		if last != nil && last.bp == l.bp && (last.Assoc == AssocNone || last.Assoc != l.Assoc) {
+			s.Restore(op.Start)
+			return nil, ambiguousErr(s, last.matched, op)
+		}
+		lhs, err = pp.parseInfix(s, l, op, lhs)
+		if err != nil {
+			return nil, err
+		}
+		last = &infixOp{op, l.bp}
+	}
+}
+
+// parseInfix 解析中缀操作符的右操作数
+// 右结合的右操作数以 bp-1 解析, 会继续消费相同 bp 的操作符, 所以把 op 传下去检查结合性
+func (pp *Pratt) parseInfix(s State, l led, op matched, lhs interface{}) (interface{}, error) {
+	rbp, last := l.bp, (*infixOp)(nil)
+	if l.Assoc == AssocRight {
+		rbp, last = l.bp-1, &infixOp{op, l.bp}
+	}
+	rhs, err := operand(s, NewParser(func(s State) (interface{}, error) { return pp.parse(s, rbp, last) }), op)
+	if err != nil {
+		return nil, err
+	}
+	return binaryFn(op.Value)(lhs, rhs), nil
+}
+
+func (pp *Pratt) parseNud(s State) (interface{}, error) {
+	for _, n := range pp.nuds {
+		if op, ok := matchOp(s, n.op); ok {
+			return operand(s, n.fn(op.Value), op)
+		}
+	}
+	return pp.term.Parse(s)
+}
+
+func (pp *Pratt) matchLed(s State) (led, matched, bool) {
+	for _, l := range pp.leds {
+		if op, ok := matchOp(s, l.op); ok {
+			op.Assoc = l.Assoc
+			return l, op, true
+		}
+	}
+	return led{}, matched{}, false
+}
@@ -0,0 +1,82 @@
+package exprparser
+
+import (
+	"strings"
+
+	"github.com/goghcrow/lexer"
+	. "github.com/goghcrow/parsec"
+)
+
+// matched 匹配到的操作符
+type matched struct {
+	Value interface{} // 操作符 parser 的返回值
+	Span
+	Assoc // 只有中缀操作符需要
+}
+
+// opGroup 同一优先级内相同结合性的中缀操作符
+type opGroup struct {
+	Parser
+	Assoc
+}
+
+func matchOp(s State, op Parser) (matched, bool) {
+	start := s.Save()
+	v, err := Try(op).Parse(s)
+	if err != nil {
+		return matched{}, false
+	}
+	return matched{Value: v, Span: Span{Start: start, End: s.Save()}}, true
+}
+
+func matchInfix(s State, g opGroup) (matched, bool) {
+	op, ok := matchOp(s, g.Parser)
+	op.Assoc = g.Assoc
+	return op, ok
+}
+
+// operand 解析 op 之后的操作数, 未消费 state 的失败替换成更明确的错误
+func operand(s State, p Parser, op matched) (interface{}, error) {
+	pos := s.Save()
+	v, err := p.Parse(s)
+	if err != nil {
+		if pos == s.Save() {
+			return nil, Trap(pos, "expect expression after `%s`", opText(s, op.Span))
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// ambiguousErr op 之后出现了同一优先级不能连用的 op2, 错误指向 op2
+func ambiguousErr(s State, op, op2 matched) error {
+	switch {
+	case op2.Assoc == AssocNone:
+		return Trap(op2.Start, "operator `%s` is non-associative, add parentheses", opText(s, op2.Span))
+	case op.Assoc == AssocNone:
+		return Trap(op2.Start, "operator `%s` is non-associative, add parentheses", opText(s, op.Span))
+	default:
+		return Trap(op2.Start, "operator `%s` is %s associative and `%s` is %s associative, add parentheses",
+			opText(s, op.Span), op.Assoc, opText(s, op2.Span), op2.Assoc)
+	}
+}
+
+// opText 重新读取 span 范围内的输入, 用于错误信息
+func opText(s State, span Span) string {
+	cur := s.Save()
+	defer s.Restore(cur)
+	s.Restore(span.Start)
+	var b strings.Builder
+	for s.Save().Idx < span.End.Idx {
+		v, ok := s.Next()
+		if !ok {
+			break
+		}
+		if tok, isTok := v.(*lexer.Token); isTok {
+			b.WriteString(tok.Lexeme)
+		} else {
+			b.WriteString(Show(v))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,210 @@
+// Package parsectest 测试文法的工具: golden 文件快照测试, 以及断言解析成功或失败位置
+//
+// golden 文件与输入文件同名, 加 .golden 后缀, 执行 go test -update 重写, e.g. go test ./example/lisp -update
+// -update 由 parsectest 注册 (已经被先初始化的包注册时复用), 测试包不需要也不能再定义 -update
+// 一次执行多个包时 (e.g. go test ./...) 未导入 parsectest 的包不认识 -update, 可以改用 PARSECTEST_UPDATE=1
+package parsectest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goghcrow/parsec"
+)
+
+// UpdateEnv 为 true 时 (e.g. PARSECTEST_UPDATE=1) 重写 golden 文件
+const UpdateEnv = "PARSECTEST_UPDATE"
+
+func init() {
+	// 其他包已经注册时不重复注册, 避免 flag redefined
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "rewrite parsectest golden files")
+	}
+}
+
+// Updating 是否重写 golden 文件: -update 或者环境变量 UpdateEnv 为 true
+func Updating() bool {
+	if v, err := strconv.ParseBool(os.Getenv(UpdateEnv)); err == nil && v {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			v, _ := g.Get().(bool)
+			return v
+		}
+	}
+	return false
+}
+
+// Golden 对 dir 中匹配 pattern (e.g. "*.lisp") 的每个文件执行 parse,
+// 用 Render 渲染结果或错误, 与 file.golden 比较, Updating 时重写 golden 文件
+// 结果用 fmt.Sprint 渲染, 包含指针等不确定输出时应先在 parse 中转换
+func Golden(t *testing.T, dir, pattern string, parse func(src string) (interface{}, error)) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no input files match %s", filepath.Join(dir, pattern))
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := parse(string(src))
+			AssertGolden(t, file+".golden", Render(string(src), v, err))
+		})
+	}
+}
+
+// AssertGolden 比较 actual 与 golden 文件, Updating 时重写 golden 文件
+func AssertGolden(t testing.TB, golden, actual string) {
+	t.Helper()
+	if Updating() {
+		if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expect, err := ioutil.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("missing golden file %s, run go test -update", golden)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(expect) != actual {
+		t.Errorf("%s mismatch, run go test -update if expected\n%s", golden, diff(string(expect), actual))
+	}
+}
+
+// Render 确定性地渲染解析的结果或错误, 错误带有所在的源码片段, e.g.
+//
+//	error: expect `)` actual end of input in pos 5 line 1 col 5
+//	   1 | (a b
+//	     |     ^
+func Render(src string, v interface{}, err error) string {
+	if err == nil {
+		return fmt.Sprintf("ok\n%v\n", v)
+	}
+	var b strings.Builder
+	b.WriteString("error: ")
+	b.WriteString(err.Error())
+	b.WriteString("\n")
+	if pos, ok := ErrorPos(err); ok {
+		b.WriteString(Excerpt(src, pos))
+	}
+	return b.String()
+}
+
+// ErrorPos 错误发生的位置, 支持 parsec.Error 与 *parsec.AbortError
+func ErrorPos(err error) (parsec.Pos, bool) {
+	var perr parsec.Error
+	if errors.As(err, &perr) {
+		return perr.Pos, true
+	}
+	var aerr *parsec.AbortError
+	if errors.As(err, &aerr) {
+		return aerr.Pos, true
+	}
+	return parsec.Pos{}, false
+}
+
+// Excerpt pos 所在的源码行以及指向列的 ^, Line Col 从 0 开始, Col 按 rune 计数
+// 二进制输入等没有行的位置返回空串
+func Excerpt(src string, pos parsec.Pos) string {
+	lines := strings.Split(src, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ""
+	}
+	line := []rune(strings.TrimSuffix(lines[pos.Line], "\r"))
+	col := pos.Col
+	if col > len(line) {
+		col = len(line)
+	}
+	// 保留 tab 使 ^ 对齐
+	pad := []rune(strings.Repeat(" ", col))
+	for i := 0; i < col; i++ {
+		if line[i] == '\t' {
+			pad[i] = '\t'
+		}
+	}
+	no := fmt.Sprintf("%4d", pos.Line+1)
+	return fmt.Sprintf("%s | %s\n%s | %s^\n", no, string(line), strings.Repeat(" ", len(no)), string(pad))
+}
+
+// AssertParses p 解析 s 成功, 返回结果
+func AssertParses(t testing.TB, p parsec.Parser, s parsec.State) interface{} {
+	t.Helper()
+	v, err := p.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected %s", describe(s, err))
+	}
+	return v
+}
+
+// AssertFailsAt p 解析 s 失败, 错误位置为 pos, 错误信息 (不含位置) 为 msg
+func AssertFailsAt(t testing.TB, p parsec.Parser, s parsec.State, pos parsec.Pos, msg string) {
+	t.Helper()
+	v, err := p.Parse(s)
+	if err == nil {
+		t.Fatalf("expect error `%s` in %s actual %v", msg, pos, v)
+	}
+	actual, ok := ErrorPos(err)
+	if !ok {
+		t.Fatalf("expect error `%s` in %s actual %s", msg, pos, err)
+	}
+	var actualMsg string
+	var perr parsec.Error
+	var aerr *parsec.AbortError
+	if errors.As(err, &perr) {
+		actualMsg = perr.Msg
+	} else if errors.As(err, &aerr) {
+		actualMsg = aerr.Err.Error()
+	}
+	if actual != pos || actualMsg != msg {
+		t.Errorf("expect error `%s` in %s actual %s", msg, pos, describe(s, err))
+	}
+}
+
+// describe 错误与源码片段, 只有 Lossless 的 State 才能取得源码
+func describe(s parsec.State, err error) string {
+	pos, ok := ErrorPos(err)
+	ll, lossless := s.(parsec.Lossless)
+	if !ok || !lossless {
+		return err.Error()
+	}
+	return err.Error() + "\n" + Excerpt(ll.Slice(parsec.Pos{}, ll.End()), pos)
+}
+
+// diff 从第一个不同的行开始显示差异
+func diff(expect, actual string) string {
+	xs := strings.Split(strings.TrimSuffix(expect, "\n"), "\n")
+	ys := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+	i := 0
+	for i < len(xs) && i < len(ys) && xs[i] == ys[i] {
+		i++
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "first difference at line %d\n", i+1)
+	for j := i; j < len(xs) && j < i+5; j++ {
+		fmt.Fprintf(&b, "- %s\n", xs[j])
+	}
+	for j := i; j < len(ys) && j < i+5; j++ {
+		fmt.Fprintf(&b, "+ %s\n", ys[j])
+	}
+	return b.String()
+}
@@ -5,6 +5,7 @@ package parsec
 // ----------------------------------------------------------------
 
 func NewRule() *SyntaxRule                                  { return &SyntaxRule{} }
+func NewNamedRule(name string) *SyntaxRule                  { return &SyntaxRule{Name: name} }
 func NewParser(p func(s State) (interface{}, error)) Parser { return parser(p) }
 
 // ----------------------------------------------------------------
@@ -17,11 +18,19 @@ type Parser interface {
 	FlatMap(f func(interface{}) Parser) Parser
 }
 
+// SyntaxRule 可以递归引用的 parser, Name 用于 Tracer 等调试工具
 type SyntaxRule struct {
+	Name    string
 	Pattern Parser
 }
 
-func (r *SyntaxRule) Parse(s State) (interface{}, error)           { return r.Pattern.Parse(s) }
+func (r *SyntaxRule) Parse(s State) (interface{}, error) {
+	if c := ContextOf(s); c != nil {
+		return c.parseRule(r, s)
+	}
+	return r.Pattern.Parse(s)
+}
+func (r *SyntaxRule) String() string                               { return r.Name }
 func (r *SyntaxRule) Map(f func(v interface{}) interface{}) Parser { return Map(r, f) }
 func (r *SyntaxRule) FlatMap(f func(v interface{}) Parser) Parser  { return FlatMap(r, f) }
 
@@ -31,6 +40,13 @@ func (r *SyntaxRule) FlatMap(f func(v interface{}) Parser) Parser  { return Flat
 
 type parser func(s State) (interface{}, error)
 
-func (p parser) Parse(s State) (interface{}, error)           { return p(s) }
+func (p parser) Parse(s State) (interface{}, error) {
+	if c := ContextOf(s); c != nil {
+		if err := c.step(s); err != nil {
+			return nil, err
+		}
+	}
+	return p(s)
+}
 func (p parser) Map(f func(v interface{}) interface{}) Parser { return Map(p, f) }
 func (p parser) FlatMap(f func(v interface{}) Parser) Parser  { return FlatMap(p, f) }
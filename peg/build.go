@@ -0,0 +1,180 @@
+package peg
+
+import (
+	"fmt"
+
+	"github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/states/bytestate"
+	"github.com/goghcrow/parsec/states/charstate"
+	"github.com/goghcrow/parsec/states/tokstate"
+)
+
+// ----------------------------------------------------------------
+// IR => Parser
+// 每条规则对应一个同名的 SyntaxRule, 可以配合 Tracer cst 使用
+// 结果: Seq Repeat 返回 []any, Choice 返回分支的结果, Opt 不匹配返回 nil, And Not 返回 nil,
+// Lit Regex Class Any 返回终结符 parser 的结果, 规则的结果经过 Options.Actions 转换
+// 注意: 同 PEG, 不支持左递归, 左递归以及可以不消耗输入的重复 (e.g. ("a"?)*) 返回错误
+// ----------------------------------------------------------------
+
+// Primitives 终结符在具体 State 上的实现
+type Primitives struct {
+	Str     func(string) parsec.Parser
+	StrFold func(string) parsec.Parser
+	Regex   func(string) parsec.Parser
+	Any     parsec.Parser
+}
+
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	CharPrimitives = Primitives{Str: charstate.Str, StrFold: charstate.StrFold, Regex: charstate.Regex, Any: charstate.AnyChar()}
+	BytePrimitives = Primitives{Str: bytestate.Str, StrFold: bytestate.StrFold, Regex: bytestate.Regex, Any: bytestate.AnyChar()}
+	// TokPrimitives 字面量匹配 token 的 Lexeme, 终结符一般通过 Options.Terminals 定义为 tokstate.Tok
+	TokPrimitives = Primitives{Str: tokstate.Str, Any: parsec.Any}
+)
+
+type Options struct {
+	Primitives Primitives // 零值使用 CharPrimitives
+
+	// Terminals 文法中没有定义为规则的标识符, e.g. {"Ident": tokstate.Tok(Ident, "ident")}
+	Terminals map[string]parsec.Parser
+
+	// Actions 按规则名注册的语义动作, 转换规则的结果
+	Actions map[string]func(v interface{}) interface{}
+
+	// Skip 非 nil 时跳过每个 Lit Regex Class Any 之后以及开始规则之前的输入, e.g. 空白与注释
+	Skip parsec.Parser
+}
+
+// Parsers Build 的结果
+type Parsers struct {
+	Start parsec.Parser // 开始规则, 包含开头的 Skip
+	Rules map[string]*parsec.SyntaxRule
+}
+
+// Build 构造 parsec.Parser, 引用未定义的规则或终结符, 动作引用不存在的规则, 终结符不被 Primitives 支持,
+// 左递归或者重复的内容可以不消耗输入时返回错误
+func (g *Grammar) Build(opts Options) (*Parsers, error) {
+	if opts.Primitives.Str == nil {
+		opts.Primitives = CharPrimitives
+	}
+	b := &builder{opts: opts, rules: map[string]*parsec.SyntaxRule{}}
+	for _, r := range g.Rules {
+		b.rules[r.Name] = parsec.NewNamedRule(r.Name)
+	}
+	for name := range opts.Actions {
+		if _, ok := b.rules[name]; !ok {
+			return nil, fmt.Errorf("action for undefined rule `%s`", name)
+		}
+	}
+	for _, r := range g.Rules {
+		p, err := b.compile(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule `%s`: %s", r.Name, err)
+		}
+		if act, ok := opts.Actions[r.Name]; ok {
+			p = p.Map(act)
+		}
+		b.rules[r.Name].Pattern = p
+	}
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+	var start parsec.Parser = b.rules[g.Rules[0].Name]
+	if opts.Skip != nil {
+		start = parsec.Right(opts.Skip, start)
+	}
+	return &Parsers{Start: start, Rules: b.rules}, nil
+}
+
+func (g *Grammar) MustBuild(opts Options) *Parsers {
+	ps, err := g.Build(opts)
+	if err != nil {
+		panic(err)
+	}
+	return ps
+}
+
+type builder struct {
+	opts  Options
+	rules map[string]*parsec.SyntaxRule
+}
+
+func (b *builder) compile(e Expr) (parsec.Parser, error) {
+	switch e := e.(type) {
+	case *Seq:
+		if len(e.Xs) == 0 {
+			return parsec.Return([]interface{}{}), nil
+		}
+		ps, err := b.compileAll(e.Xs)
+		return parsec.List(ps...), err
+	case *Choice:
+		ps, err := b.compileAll(e.Xs)
+		return parsec.Alt(ps...), err // parsec.Alt 自动回溯, 同 PEG 的有序选择
+	case *Repeat:
+		p, err := b.compile(e.X)
+		if e.Min == 0 {
+			return parsec.Many(p), err
+		}
+		return parsec.Many1(p), err
+	case *Opt:
+		p, err := b.compile(e.X)
+		return parsec.Option(p, nil), err
+	case *And:
+		p, err := b.compile(e.X)
+		return parsec.Right(parsec.LookAhead(p), parsec.Nil), err
+	case *Not:
+		p, err := b.compile(e.X)
+		return parsec.NotFollowedBy(p), err
+	case *Lit:
+		if e.Fold {
+			return b.terminal(b.opts.Primitives.StrFold, e.Text, "case-insensitive literal")
+		}
+		return b.terminal(b.opts.Primitives.Str, e.Text, "literal")
+	case *Regex:
+		return b.terminal(b.opts.Primitives.Regex, e.Pattern, "regex")
+	case *Class:
+		return b.terminal(b.opts.Primitives.Regex, e.Pattern, "class")
+	case *Any:
+		if b.opts.Primitives.Any == nil {
+			return nil, fmt.Errorf("`.` not supported")
+		}
+		return b.skip(b.opts.Primitives.Any), nil
+	case *Ref:
+		if r, ok := b.rules[e.Name]; ok {
+			return r, nil
+		}
+		if t, ok := b.opts.Terminals[e.Name]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("undefined `%s`", e.Name)
+	default:
+		return nil, fmt.Errorf("unknown expr %T", e)
+	}
+}
+
+func (b *builder) compileAll(xs []Expr) ([]parsec.Parser, error) {
+	ps := make([]parsec.Parser, len(xs))
+	for i, x := range xs {
+		p, err := b.compile(x)
+		if err != nil {
+			return nil, err
+		}
+		ps[i] = p
+	}
+	return ps, nil
+}
+
+func (b *builder) terminal(prim func(string) parsec.Parser, s, kind string) (parsec.Parser, error) {
+	if prim == nil {
+		return nil, fmt.Errorf("%s %s not supported", kind, s)
+	}
+	return b.skip(prim(s)), nil
+}
+
+func (b *builder) skip(p parsec.Parser) parsec.Parser {
+	if b.opts.Skip == nil {
+		return p
+	}
+	return parsec.Left(p, b.opts.Skip)
+}
@@ -0,0 +1,172 @@
+package peg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// Check
+// 同 PEG, 以下文法在解析时会无限递归, Build 与 Generate 都直接返回错误
+// 1. 重复 (* +) 的内容可以不消耗输入而成功, e.g. A <- ("a"?)*
+// 2. 左递归, 包括间接左递归与可空前缀之后的递归, e.g. A <- A "x" / "x", A <- B? A "x"
+// Options.Terminals 中的终结符视为总是消耗输入
+// ----------------------------------------------------------------
+
+func (g *Grammar) check() error {
+	c := &checker{g: g, nullable: map[string]bool{}}
+	// 可空规则的不动点
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			if !c.nullable[r.Name] && c.isNullable(r.Expr) {
+				c.nullable[r.Name] = true
+				changed = true
+			}
+		}
+	}
+	for _, r := range g.Rules {
+		if err := c.checkRepeat(r.Expr); err != nil {
+			return fmt.Errorf("rule `%s`: %s", r.Name, err)
+		}
+	}
+	// 左递归: 规则在不消耗输入的情况下可以到达的规则, 成环即左递归
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := map[string]int{}
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			i := 0
+			for path[i] != name {
+				i++
+			}
+			return fmt.Errorf("rule `%s` is left recursive: %s -> %s", name, strings.Join(path[i:], " -> "), name)
+		case done:
+			return nil
+		}
+		marks[name] = visiting
+		path = append(path, name)
+		for _, next := range c.leftRefs(g.Rule(name).Expr, nil) {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[name] = done
+		return nil
+	}
+	for _, r := range g.Rules {
+		if err := visit(r.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type checker struct {
+	g        *Grammar
+	nullable map[string]bool // 可以不消耗输入而成功的规则
+}
+
+func (c *checker) isNullable(e Expr) bool {
+	switch e := e.(type) {
+	case *Seq:
+		for _, x := range e.Xs {
+			if !c.isNullable(x) {
+				return false
+			}
+		}
+		return true
+	case *Choice:
+		for _, x := range e.Xs {
+			if c.isNullable(x) {
+				return true
+			}
+		}
+		return false
+	case *Repeat:
+		return e.Min == 0 || c.isNullable(e.X)
+	case *Opt, *And, *Not:
+		return true
+	case *Lit:
+		return e.Text == ""
+	case *Regex:
+		return matchesEmpty(e.Pattern)
+	case *Class:
+		return matchesEmpty(e.Pattern)
+	case *Ref:
+		return c.nullable[e.Name]
+	default:
+		return false
+	}
+}
+
+// matchesEmpty 非法的正则在 Build 时报错, 这里视为不可空
+func matchesEmpty(pattern string) bool {
+	re, err := regexp.Compile(`^(?:` + pattern + `)`)
+	return err == nil && re.MatchString("")
+}
+
+func (c *checker) checkRepeat(e Expr) error {
+	var xs []Expr
+	switch e := e.(type) {
+	case *Seq:
+		xs = e.Xs
+	case *Choice:
+		xs = e.Xs
+	case *Repeat:
+		if c.isNullable(e.X) {
+			return fmt.Errorf("%s can repeat without consuming input", e)
+		}
+		xs = []Expr{e.X}
+	case *Opt:
+		xs = []Expr{e.X}
+	case *And:
+		xs = []Expr{e.X}
+	case *Not:
+		xs = []Expr{e.X}
+	}
+	for _, x := range xs {
+		if err := c.checkRepeat(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leftRefs e 在消耗输入之前可能调用的规则
+func (c *checker) leftRefs(e Expr, refs []string) []string {
+	switch e := e.(type) {
+	case *Seq:
+		for _, x := range e.Xs {
+			refs = c.leftRefs(x, refs)
+			if !c.isNullable(x) {
+				break
+			}
+		}
+	case *Choice:
+		for _, x := range e.Xs {
+			refs = c.leftRefs(x, refs)
+		}
+	case *Repeat:
+		refs = c.leftRefs(e.X, refs)
+	case *Opt:
+		refs = c.leftRefs(e.X, refs)
+	case *And:
+		refs = c.leftRefs(e.X, refs)
+	case *Not:
+		refs = c.leftRefs(e.X, refs)
+	case *Ref:
+		if c.g.Rule(e.Name) != nil {
+			refs = append(refs, e.Name)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package peg
+
+import (
+	"math/rand"
+	"runtime/debug"
+	"testing"
+)
+
+// Fuzz 用 n 个生成的句子作为种子语料执行原生 fuzz, 检查 parse 对任何输入都不会 panic,
+// 并且生成的句子都能解析成功, e.g.
+//
+//	func FuzzCalc(f *testing.F) {
+//		sampler.Fuzz(f, 1, 100, func(s string) error {
+//			_, err := parsec.ExpectEof(ps.Start).Parse(charstate.NewState(s))
+//			return err
+//		})
+//	}
+func (s *Sampler) Fuzz(f *testing.F, seed int64, n int, parse func(string) error) {
+	f.Helper()
+	rnd := rand.New(rand.NewSource(seed))
+	sentences := map[string]bool{}
+	for i := 0; i < n; i++ {
+		in := s.Sample(rnd)
+		sentences[in] = true
+		f.Add(in)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		var err error
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic on %q: %v\n%s", in, r, debug.Stack())
+				}
+			}()
+			err = parse(in)
+		}()
+		if err != nil && sentences[in] {
+			t.Errorf("generated sentence %q: %s", in, err)
+		}
+	})
+}
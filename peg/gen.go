@@ -0,0 +1,467 @@
+package peg
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------
+// Code Generator
+// 把 IR 生成为不使用闭包的 Go 代码, 原语直接内联在 charstate / bytestate 的输入上
+// 生成的 Parser 与 Build(Options{Primitives: Char/BytePrimitives, Skip: Optional(Regex(skip))}) 的
+// 结果, 错误 (包括位置) 以及结束位置完全一致
+// 不支持 Options.Terminals, 不使用 Context (没有 Tracer 与 Limits)
+// ----------------------------------------------------------------
+
+type GenOptions struct {
+	Package string // 生成代码的包名
+	State   string // "char" (默认) 或 "byte"
+	Skip    string // 非空时为跳过空白等的正则, 等价于 Options.Skip = Optional(Regex(Skip))
+	Source  string // 文法来源, 写入生成代码的注释
+}
+
+// Generate 生成 Go 源码, 已经 gofmt
+func (g *Grammar) Generate(opts GenOptions) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package required")
+	}
+	if opts.State == "" {
+		opts.State = "char"
+	}
+	if opts.State != "char" && opts.State != "byte" {
+		return nil, fmt.Errorf("unknown state %s", opts.State)
+	}
+	e := &emitter{g: g, opts: opts, rules: map[string]int{}}
+	for i, r := range g.Rules {
+		e.rules[r.Name] = i
+	}
+	if err := e.file(); err != nil {
+		return nil, err
+	}
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+	src, err := format.Source([]byte(e.b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %s", err)
+	}
+	return src, nil
+}
+
+type emitter struct {
+	g       *Grammar
+	opts    GenOptions
+	b       *strings.Builder
+	n       int            // 临时变量计数
+	rules   map[string]int // 规则名 => 下标
+	regexes []string       // 生成代码中 regexes patterns 的下标, Skip 为 0
+}
+
+func (e *emitter) printf(f string, a ...interface{}) { _, _ = fmt.Fprintf(e.b, f, a...) }
+
+func (e *emitter) tmp(prefix string) string {
+	e.n++
+	return prefix + strconv.Itoa(e.n)
+}
+
+// regex 登记正则, 返回编号
+func (e *emitter) regex(pattern string) int {
+	for i, p := range e.regexes {
+		if p == pattern {
+			return i
+		}
+	}
+	e.regexes = append(e.regexes, pattern)
+	return len(e.regexes) - 1
+}
+
+func (e *emitter) file() error {
+	if e.opts.Skip != "" {
+		e.regex(e.opts.Skip) // regexes[0]
+	}
+	body := &strings.Builder{}
+	e.b = body
+	for i, r := range e.g.Rules {
+		e.printf("\n// %s\n", r)
+		e.printf("func (s *state) rule%d() (v interface{}, err error) {\n", i)
+		if err := e.expr(r.Expr); err != nil {
+			return fmt.Errorf("rule `%s`: %s", r.Name, err)
+		}
+		e.printf("if err == nil {\nif f := s.actions[%s]; f != nil {\nv = f(v)\n}\n}\n", strconv.Quote(r.Name))
+		e.printf("return\n}\n")
+	}
+
+	e.b = &strings.Builder{}
+	e.printf("// Code generated by parsecgen. DO NOT EDIT.\n")
+	if e.opts.Source != "" {
+		e.printf("// source: %s\n", e.opts.Source)
+	}
+	e.printf("\npackage %s\n\n", e.opts.Package)
+	if e.opts.State == "char" {
+		e.printf("import (\n\"regexp\"\n\"unicode\"\n\"unicode/utf8\"\n\n\"github.com/goghcrow/parsec\"\n)\n\n")
+	} else {
+		e.printf("import (\n\"regexp\"\n\n\"github.com/goghcrow/parsec\"\n)\n\n")
+	}
+	e.printf("// Grammar 生成代码使用的文法\nconst Grammar = %s\n\n", strconv.Quote(e.g.String()))
+	e.printf("var patterns = []string{\n")
+	for _, p := range e.regexes {
+		e.printf("%s,\n", strconv.Quote(p))
+	}
+	e.printf("}\n\nvar regexes = []*regexp.Regexp{\n")
+	for _, p := range e.regexes {
+		e.printf("regexp.MustCompile(%s),\n", strconv.Quote("^(?:"+p+")"))
+	}
+	e.printf("}\n")
+	e.printf("%s", runtimeHead)
+	if e.opts.Skip != "" {
+		e.printf("%s", runtimeSkip)
+	} else {
+		e.printf("\nfunc (s *state) skip() {}\n")
+	}
+	if e.opts.State == "char" {
+		e.printf("%s", runtimeChar)
+	} else {
+		e.printf("%s", runtimeByte)
+	}
+	e.printf("%s", body.String())
+	return nil
+}
+
+// expr 生成计算 e 的语句, 结果写入 v err
+// 与 combinator 的语义保持一致, 尤其是失败时 state 的位置
+func (e *emitter) expr(x Expr) error {
+	switch x := x.(type) {
+	case *Seq:
+		if len(x.Xs) == 0 {
+			e.printf("v, err = []interface{}{}, nil\n")
+			return nil
+		}
+		xs := e.tmp("xs")
+		e.printf("%s := make([]interface{}, 0, %d)\n", xs, len(x.Xs))
+		for i, it := range x.Xs {
+			if err := e.expr(it); err != nil {
+				return err
+			}
+			e.printf("if err == nil {\n%s = append(%s, v)\n", xs, xs)
+			if i == len(x.Xs)-1 {
+				e.printf("v = %s\n", xs)
+			}
+		}
+		e.printf("%s\n", strings.Repeat("}", len(x.Xs)))
+	case *Choice:
+		// Either(Try(a), b): 除了最后一个分支, 失败都恢复位置
+		for i, it := range x.Xs {
+			if i < len(x.Xs)-1 {
+				save := e.tmp("save")
+				e.printf("%s := s.save()\n", save)
+				if err := e.expr(it); err != nil {
+					return err
+				}
+				e.printf("if err != nil {\ns.restore(%s)\n", save)
+			} else if err := e.expr(it); err != nil {
+				return err
+			}
+		}
+		e.printf("%s\n", strings.Repeat("}", len(x.Xs)-1))
+	case *Repeat:
+		xs := e.tmp("xs")
+		if x.Min == 1 {
+			// Many1: 第一次失败不恢复位置
+			if err := e.expr(x.X); err != nil {
+				return err
+			}
+			e.printf("if err == nil {\n%s := []interface{}{v}\n", xs)
+		} else {
+			e.printf("{\n%s := []interface{}{}\n", xs)
+		}
+		// 同 Build, 可以不消耗输入的重复已经被 check 拒绝
+		save := e.tmp("save")
+		e.printf("for {\n%s := s.save()\n", save)
+		if err := e.expr(x.X); err != nil {
+			return err
+		}
+		e.printf("if err != nil {\ns.restore(%s)\nbreak\n}\n", save)
+		e.printf("%s = append(%s, v)\n}\n", xs, xs)
+		e.printf("v, err = %s, nil\n}\n", xs)
+	case *Opt:
+		save := e.tmp("save")
+		e.printf("%s := s.save()\n", save)
+		if err := e.expr(x.X); err != nil {
+			return err
+		}
+		e.printf("if err != nil {\ns.restore(%s)\nv, err = nil, nil\n}\n", save)
+	case *And:
+		// Right(LookAhead(p), Nil): 失败不恢复位置
+		save := e.tmp("save")
+		e.printf("%s := s.save()\n", save)
+		if err := e.expr(x.X); err != nil {
+			return err
+		}
+		e.printf("if err == nil {\ns.restore(%s)\n}\nv = nil\n", save)
+	case *Not:
+		// NotFollowedBy: p 成功时不恢复位置
+		save := e.tmp("save")
+		e.printf("%s := s.save()\n", save)
+		if err := e.expr(x.X); err != nil {
+			return err
+		}
+		e.printf("if err == nil {\nerr = parsec.Trap(%s.Pos, \"unexpect `%%s`\", parsec.Show(v))\n", save)
+		e.printf("} else {\ns.restore(%s)\nerr = nil\n}\nv = nil\n", save)
+	case *Lit:
+		if x.Fold {
+			e.printf("v, err = s.strFold(%s)\n", strconv.Quote(x.Text))
+		} else {
+			e.lit(x.Text)
+		}
+		e.skip()
+	case *Regex:
+		e.printf("v, err = s.regex(%d)\n", e.regex(x.Pattern))
+		e.skip()
+	case *Class:
+		e.printf("v, err = s.regex(%d)\n", e.regex(x.Pattern))
+		e.skip()
+	case *Any:
+		e.printf("v, err = s.any()\n")
+		e.skip()
+	case *Ref:
+		i, ok := e.rules[x.Name]
+		if !ok {
+			return fmt.Errorf("undefined `%s`", x.Name)
+		}
+		e.printf("v, err = s.rule%d()\n", i)
+	default:
+		return fmt.Errorf("unknown expr %T", x)
+	}
+	return nil
+}
+
+// lit 内联字面量匹配, 逐个字符比较, 失败时停在第一个不匹配的位置
+func (e *emitter) lit(text string) {
+	var units []string
+	if e.opts.State == "char" {
+		for _, r := range text {
+			units = append(units, strconv.QuoteRune(r))
+		}
+	} else {
+		for i := 0; i < len(text); i++ {
+			if text[i] < utf8.RuneSelf {
+				units = append(units, strconv.QuoteRune(rune(text[i])))
+			} else {
+				units = append(units, fmt.Sprintf("%#02x", text[i]))
+			}
+		}
+	}
+	e.printf("// %s\n", strconv.Quote(text))
+	if len(units) == 0 {
+		e.printf("err = nil\n")
+	}
+	for i, u := range units {
+		if i == 0 {
+			e.printf("err = s.char(%s)\n", u)
+		} else {
+			e.printf("if err == nil {\nerr = s.char(%s)\n}\n", u)
+		}
+	}
+	e.printf("v = nil\nif err == nil {\nv = %s\n}\n", strconv.Quote(text))
+}
+
+func (e *emitter) skip() {
+	if e.opts.Skip != "" {
+		e.printf("if err == nil {\ns.skip()\n}\n")
+	}
+}
+
+// ----------------------------------------------------------------
+// Runtime
+// 原语与 charstate / bytestate 中的实现一一对应, 修改时需要保持同步
+// ----------------------------------------------------------------
+
+const runtimeHead = `
+// Parser 由 parsecgen 生成, Actions 同 peg.Options.Actions
+type Parser struct {
+	Actions map[string]func(v interface{}) interface{}
+}
+
+// Parse 从开始规则解析 src, 返回结果, 结束位置与错误
+func (p *Parser) Parse(src string) (interface{}, parsec.Pos, error) {
+	s := &state{src: src, actions: p.Actions}
+	s.skip()
+	v, err := s.rule0()
+	if err != nil {
+		return nil, s.Pos, err
+	}
+	return v, s.Pos, nil
+}
+
+type state struct {
+	src string
+	off int // src 中的字节偏移
+	parsec.Pos
+	actions map[string]func(v interface{}) interface{}
+}
+
+type mark struct {
+	parsec.Pos
+	off int
+}
+
+func (s *state) save() mark      { return mark{s.Pos, s.off} }
+func (s *state) restore(m mark) { s.Pos, s.off = m.Pos, m.off }
+
+func (s *state) regex(i int) (interface{}, error) {
+	loc := regexes[i].FindStringIndex(s.src[s.off:])
+	if loc == nil || loc[1] == 0 {
+		return nil, parsec.Trap(s.Pos, "expect pattern '%s'", patterns[i])
+	}
+	v := s.src[s.off : s.off+loc[1]]
+	s.advance(v)
+	return v, nil
+}
+`
+
+const runtimeSkip = `
+func (s *state) skip() {
+	if loc := regexes[0].FindStringIndex(s.src[s.off:]); loc != nil && loc[1] > 0 {
+		s.advance(s.src[s.off : s.off+loc[1]])
+	}
+}
+`
+
+const runtimeChar = `
+func (s *state) step(r rune) {
+	s.Idx++
+	if r == '\n' {
+		s.Line++
+		s.Col = 0
+	} else {
+		s.Col++
+	}
+}
+
+func (s *state) advance(str string) {
+	for _, r := range str {
+		s.step(r)
+	}
+	s.off += len(str)
+}
+
+func (s *state) expect(expect string) error {
+	r, n := utf8.DecodeRuneInString(s.src[s.off:])
+	if n == 0 {
+		return parsec.Trap(s.Pos, "expect ` + "`%s`" + ` actual end of input", expect)
+	}
+	return parsec.Trap(s.Pos, "expect ` + "`%s`" + ` actual ` + "`%s`" + `", expect, string(r))
+}
+
+func (s *state) char(c rune) error {
+	r, n := utf8.DecodeRuneInString(s.src[s.off:])
+	if n == 0 || r != c {
+		return s.expect(string(c))
+	}
+	s.off += n
+	s.step(r)
+	return nil
+}
+
+func (s *state) any() (interface{}, error) {
+	r, n := utf8.DecodeRuneInString(s.src[s.off:])
+	if n == 0 {
+		return nil, s.expect("any rune")
+	}
+	s.off += n
+	s.step(r)
+	return r, nil
+}
+
+func (s *state) strFold(str string) (interface{}, error) {
+	from := s.off
+	for _, c := range str {
+		r, n := utf8.DecodeRuneInString(s.src[s.off:])
+		if n == 0 || !equalFold(c, r) {
+			return nil, s.expect(string(c))
+		}
+		s.off += n
+		s.step(r)
+	}
+	return s.src[from:s.off], nil
+}
+
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+`
+
+const runtimeByte = `
+func (s *state) step(b byte) {
+	s.Idx++
+	if b == '\n' {
+		s.Line++
+		s.Col = 0
+	} else {
+		s.Col++
+	}
+}
+
+func (s *state) advance(str string) {
+	for i := 0; i < len(str); i++ {
+		s.step(str[i])
+	}
+	s.off += len(str)
+}
+
+func (s *state) expect(expect string) error {
+	if s.off >= len(s.src) {
+		return parsec.Trap(s.Pos, "expect ` + "`%s`" + ` actual end of input", expect)
+	}
+	return parsec.Trap(s.Pos, "expect ` + "`%s`" + ` actual ` + "`%s`" + `", expect, string(rune(s.src[s.off])))
+}
+
+func (s *state) char(c byte) error {
+	if s.off >= len(s.src) || s.src[s.off] != c {
+		return s.expect(string(rune(c)))
+	}
+	s.off++
+	s.step(c)
+	return nil
+}
+
+func (s *state) any() (interface{}, error) {
+	if s.off >= len(s.src) {
+		return nil, s.expect("any byte")
+	}
+	b := s.src[s.off]
+	s.off++
+	s.step(b)
+	return b, nil
+}
+
+func (s *state) strFold(str string) (interface{}, error) {
+	from := s.off
+	for i := 0; i < len(str); i++ {
+		if s.off >= len(s.src) || lower(s.src[s.off]) != lower(str[i]) {
+			return nil, s.expect(string(rune(str[i])))
+		}
+		s.step(s.src[s.off])
+		s.off++
+	}
+	return s.src[from:s.off], nil
+}
+
+func lower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+`
@@ -0,0 +1,131 @@
+package peg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// IR
+// Load 得到的文法, 可以被 Build 构造 Parser, 也可以被代码生成 随机生成等工具遍历
+// ----------------------------------------------------------------
+
+type Grammar struct {
+	Rules []*Rule // 第一条规则为开始规则
+}
+
+type Rule struct {
+	Name string
+	Expr Expr
+}
+
+type Expr interface {
+	String() string
+	prec() int
+}
+
+type (
+	Seq    struct{ Xs []Expr } // a b c
+	Choice struct{ Xs []Expr } // a / b / c, 有序选择
+	Repeat struct {            // a* a+
+		X   Expr
+		Min int // 0 或 1
+	}
+	Opt struct{ X Expr } // a?
+	And struct{ X Expr } // &a, 不消耗输入
+	Not struct{ X Expr } // !a, 不消耗输入
+	Lit struct {         // "abc" 'abc', "abc"i 忽略大小写
+		Text string
+		Fold bool
+	}
+	Regex struct{ Pattern string } // `re`, Go regexp 语法
+	Class struct{ Pattern string } // [a-z], 同 regexp 的字符类
+	Any   struct{}                 // .
+	Ref   struct{ Name string }    // 规则或者 Options.Terminals 中的终结符
+)
+
+// Rule 按名字查找规则, 不存在返回 nil
+func (g *Grammar) Rule(name string) *Rule {
+	for _, r := range g.Rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// String 规范化的文法文本, 可以被 Load 重新加载
+func (g *Grammar) String() string {
+	var b strings.Builder
+	for _, r := range g.Rules {
+		b.WriteString(r.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (r *Rule) String() string { return r.Name + " <- " + r.Expr.String() }
+
+// 优先级: Choice < Seq < 前缀 (& !) < 后缀 (? * +) < Primary
+const (
+	precChoice = iota
+	precSeq
+	precPrefix
+	precSuffix
+	precPrimary
+)
+
+func (e *Seq) prec() int    { return precSeq }
+func (e *Choice) prec() int { return precChoice }
+func (e *Repeat) prec() int { return precSuffix }
+func (e *Opt) prec() int    { return precSuffix }
+func (e *And) prec() int    { return precPrefix }
+func (e *Not) prec() int    { return precPrefix }
+func (e *Lit) prec() int    { return precPrimary }
+func (e *Regex) prec() int  { return precPrimary }
+func (e *Class) prec() int  { return precPrimary }
+func (e *Any) prec() int    { return precPrimary }
+func (e *Ref) prec() int    { return precPrimary }
+
+// wrap e 的优先级低于 prec 时加括号
+func wrap(e Expr, prec int) string {
+	if e.prec() < prec {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+func join(xs []Expr, sep string, prec int) string {
+	strs := make([]string, len(xs))
+	for i, x := range xs {
+		strs[i] = wrap(x, prec)
+	}
+	return strings.Join(strs, sep)
+}
+
+func (e *Seq) String() string {
+	if len(e.Xs) == 0 {
+		return "()"
+	}
+	return join(e.Xs, " ", precSeq+1)
+}
+func (e *Choice) String() string { return join(e.Xs, " / ", precSeq) }
+func (e *Repeat) String() string {
+	if e.Min == 0 {
+		return wrap(e.X, precPrimary) + "*"
+	}
+	return wrap(e.X, precPrimary) + "+"
+}
+func (e *Opt) String() string   { return wrap(e.X, precPrimary) + "?" }
+func (e *And) String() string   { return "&" + wrap(e.X, precSuffix) }
+func (e *Not) String() string   { return "!" + wrap(e.X, precSuffix) }
+func (e *Regex) String() string { return "`" + e.Pattern + "`" }
+func (e *Class) String() string { return e.Pattern }
+func (e *Any) String() string   { return "." }
+func (e *Ref) String() string   { return e.Name }
+func (e *Lit) String() string {
+	if e.Fold {
+		return strconv.Quote(e.Text) + "i"
+	}
+	return strconv.Quote(e.Text)
+}
@@ -0,0 +1,155 @@
+package peg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goghcrow/parsec"
+	"github.com/goghcrow/parsec/states/charstate"
+)
+
+// ----------------------------------------------------------------
+// PEG 文法文本 => IR, 文法本身也用 parsec 解析
+//
+//	Grammar    <- Spacing Definition+ !.
+//	Definition <- Identifier ("<-" / "=" / ":=") Expression ";"?
+//	Expression <- Sequence (("/" / "|") Sequence)*
+//	Sequence   <- Prefix*
+//	Prefix     <- ("&" / "!")? Suffix
+//	Suffix     <- Primary ("?" / "*" / "+")?
+//	Primary    <- Identifier !("<-" / "=" / ":=") / "(" Expression ")" / Literal / Class / Regex / "."
+//	Literal    <- ["] ... ["] "i"? / ['] ... ['] "i"?
+//	Class      <- "[" ... "]"
+//	Regex      <- "`" ... "`"
+//	Comment    <- "#" ... EndOfLine
+// ----------------------------------------------------------------
+
+// Load 解析 PEG 文法文本
+func Load(src string) (*Grammar, error) {
+	v, err := grammar.Parse(charstate.NewState(src))
+	if aerr, ok := err.(*parsec.AbortError); ok {
+		// 非法的字面量, 见 quoted
+		return nil, parsec.Error{Pos: aerr.Pos, Msg: aerr.Err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	g := &Grammar{}
+	for _, x := range v.([]interface{}) {
+		r := x.(*Rule)
+		if g.Rule(r.Name) != nil {
+			return nil, fmt.Errorf("duplicate rule `%s`", r.Name)
+		}
+		g.Rules = append(g.Rules, r)
+	}
+	if len(g.Rules) == 0 {
+		return nil, fmt.Errorf("empty grammar")
+	}
+	return g, nil
+}
+
+func MustLoad(src string) *Grammar {
+	g, err := Load(src)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+var grammar = func() parsec.Parser {
+	comment := parsec.Right(charstate.Char('#'), parsec.SkipMany(charstate.NoneOf("\n")))
+	spacing := parsec.SkipMany(parsec.Alt(charstate.Space, comment))
+	tok := func(p parsec.Parser) parsec.Parser { return parsec.Left(p, spacing) }
+	sym := func(s string) parsec.Parser { return tok(charstate.Str(s)) }
+
+	ident := tok(charstate.Regex(`[A-Za-z_][A-Za-z0-9_]*`))
+	arrow := parsec.Alt(sym("<-"), sym(":="), sym("="))
+
+	foldSuffix := parsec.Left(charstate.Char('i'), parsec.NotFollowedBy(charstate.Regex(`[A-Za-z0-9_]`)))
+	fold := parsec.Option(parsec.Right(foldSuffix, parsec.Return(true)), false)
+	quoted := func(q string) parsec.Parser {
+		raw := charstate.Regex(q + `(?:[^` + q + `\\]|\\.)*` + q)
+		return parsec.NewParser(func(st parsec.State) (interface{}, error) {
+			pos := st.Save()
+			v, err := raw.Parse(st)
+			if err != nil {
+				return nil, err
+			}
+			s := strings.ReplaceAll(v.(string)[1:len(v.(string))-1], `\`+q, q)
+			s, err = strconv.Unquote(`"` + strings.ReplaceAll(s, `"`, `\"`) + `"`)
+			if err != nil {
+				// 非法转义, e.g. "\q", 普通的失败会被 Alt 回溯为其他分支的错误, 中止解析直接交给 Load 返回
+				return nil, parsec.ContextOf(st).Abort(pos, fmt.Errorf("invalid literal %s: %s", v, err))
+			}
+			return s, nil
+		})
+	}
+	literal := tok(parsec.Seq(parsec.Alt(quoted(`"`), quoted(`'`)), fold, func(text, fold interface{}) interface{} {
+		return &Lit{Text: text.(string), Fold: fold.(bool)}
+	}))
+	class := tok(charstate.Regex(`\[(?:[^\]\\]|\\.)*\]`).Map(func(v interface{}) interface{} { return &Class{v.(string)} }))
+	regex := tok(charstate.Regex("`[^`]*`").Map(func(v interface{}) interface{} {
+		s := v.(string)
+		return &Regex{s[1 : len(s)-1]}
+	}))
+
+	Expression := parsec.NewNamedRule("Expression")
+	primary := parsec.Alt(
+		parsec.Left(ident, parsec.NotFollowedBy(arrow)).Map(func(v interface{}) interface{} { return &Ref{v.(string)} }),
+		parsec.Mid(sym("("), Expression, sym(")")),
+		literal,
+		class,
+		regex,
+		parsec.Right(sym("."), parsec.Return(&Any{})),
+	)
+	suffix := parsec.Seq(primary, parsec.Option(parsec.Alt(sym("?"), sym("*"), sym("+")), ""), func(x, op interface{}) interface{} {
+		switch op {
+		case "?":
+			return &Opt{x.(Expr)}
+		case "*":
+			return &Repeat{x.(Expr), 0}
+		case "+":
+			return &Repeat{x.(Expr), 1}
+		default:
+			return x
+		}
+	})
+	prefix := parsec.Seq(parsec.Option(parsec.Alt(sym("&"), sym("!")), ""), suffix, func(op, x interface{}) interface{} {
+		switch op {
+		case "&":
+			return &And{x.(Expr)}
+		case "!":
+			return &Not{x.(Expr)}
+		default:
+			return x
+		}
+	})
+	sequence := parsec.Many(prefix).Map(func(v interface{}) interface{} {
+		xs := exprs(v)
+		if len(xs) == 1 {
+			return xs[0]
+		}
+		return &Seq{xs}
+	})
+	Expression.Pattern = parsec.SepBy1(sequence, parsec.Alt(sym("/"), sym("|"))).Map(func(v interface{}) interface{} {
+		xs := exprs(v)
+		if len(xs) == 1 {
+			return xs[0]
+		}
+		return &Choice{xs}
+	})
+	definition := parsec.Seq(parsec.Left(ident, arrow), parsec.Left(Expression, parsec.Optional(sym(";"))), func(name, x interface{}) interface{} {
+		return &Rule{Name: name.(string), Expr: x.(Expr)}
+	})
+	return parsec.Right(spacing, parsec.ManyTill(definition, parsec.Eof))
+}()
+
+func exprs(v interface{}) []Expr {
+	xs := v.([]interface{})
+	es := make([]Expr, len(xs))
+	for i, x := range xs {
+		es[i] = x.(Expr)
+	}
+	return es
+}
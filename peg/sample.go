@@ -0,0 +1,398 @@
+package peg
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// ----------------------------------------------------------------
+// Sampler
+// 按文法随机生成句子, 用于 fuzz 下游的编译器等
+// 谓词 & ! 不参与生成, PEG 的有序选择也可能拒绝按 CFG 语义生成的句子,
+// 所以生成的句子不保证都能被解析, 用 Fuzz 检查
+// ----------------------------------------------------------------
+
+type SampleOptions struct {
+	MaxDepth  int                                // 规则嵌套超过 MaxDepth 后选择最快结束的分支, 默认 16
+	MaxRepeat int                                // * + 以及正则中重复的最大次数, 默认 3
+	Weights   map[string][]int                   // 规则名 => 规则顶层选择中各分支的权重, 默认相同
+	Terminals map[string]func(*rand.Rand) string // 同 Options.Terminals, 生成终结符的文本
+	Sep       string                             // 插在每个终结符之后, 对应 Options.Skip, e.g. " "
+}
+
+type Sampler struct {
+	g       *Grammar
+	opts    SampleOptions
+	rules   map[string]*Rule
+	cost    map[string]int // 规则结束生成需要的最少嵌套层数
+	weights map[*Choice][]int
+	regexes map[string]*syntax.Regexp
+}
+
+// Sampler 检查文法并构造 Sampler, 引用未定义的规则或终结符, 非法的正则, 权重个数不匹配,
+// 以及无法结束的规则 (e.g. A <- "a" A) 返回错误
+func (g *Grammar) Sampler(opts SampleOptions) (*Sampler, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 16
+	}
+	if opts.MaxRepeat <= 0 {
+		opts.MaxRepeat = 3
+	}
+	s := &Sampler{
+		g:       g,
+		opts:    opts,
+		rules:   map[string]*Rule{},
+		cost:    map[string]int{},
+		weights: map[*Choice][]int{},
+		regexes: map[string]*syntax.Regexp{},
+	}
+	for _, r := range g.Rules {
+		s.rules[r.Name] = r
+		s.cost[r.Name] = math.MaxInt32
+	}
+	for name, ws := range opts.Weights {
+		r, ok := s.rules[name]
+		if !ok {
+			return nil, fmt.Errorf("weights for undefined rule `%s`", name)
+		}
+		c, ok := r.Expr.(*Choice)
+		if !ok || len(c.Xs) != len(ws) {
+			return nil, fmt.Errorf("rule `%s`: expect %d weights", name, alternatives(r.Expr))
+		}
+		s.weights[c] = ws
+	}
+	for _, r := range g.Rules {
+		if err := s.check(r.Expr); err != nil {
+			return nil, fmt.Errorf("rule `%s`: %s", r.Name, err)
+		}
+	}
+	// 不动点求每条规则的最小嵌套层数
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			if c := s.minCost(r.Expr); c < s.cost[r.Name] {
+				s.cost[r.Name] = c
+				changed = true
+			}
+		}
+	}
+	for _, r := range g.Rules {
+		if s.cost[r.Name] == math.MaxInt32 {
+			return nil, fmt.Errorf("rule `%s` never terminates", r.Name)
+		}
+	}
+	return s, nil
+}
+
+func (g *Grammar) MustSampler(opts SampleOptions) *Sampler {
+	s, err := g.Sampler(opts)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Sample 从开始规则生成句子
+func (s *Sampler) Sample(rnd *rand.Rand) string { return s.SampleRule(rnd, s.g.Rules[0].Name) }
+
+// SampleRule 从规则 name 生成句子, 规则不存在时 panic
+func (s *Sampler) SampleRule(rnd *rand.Rand, name string) string {
+	r, ok := s.rules[name]
+	if !ok {
+		panic(fmt.Sprintf("undefined rule `%s`", name))
+	}
+	var b strings.Builder
+	s.gen(&b, rnd, r.Expr, 0)
+	return b.String()
+}
+
+func alternatives(e Expr) int {
+	if c, ok := e.(*Choice); ok {
+		return len(c.Xs)
+	}
+	return 1
+}
+
+func (s *Sampler) check(e Expr) error {
+	switch e := e.(type) {
+	case *Seq:
+		return s.checkAll(e.Xs)
+	case *Choice:
+		return s.checkAll(e.Xs)
+	case *Repeat:
+		return s.check(e.X)
+	case *Opt:
+		return s.check(e.X)
+	case *And:
+		return s.check(e.X)
+	case *Not:
+		return s.check(e.X)
+	case *Regex:
+		return s.compile(e.Pattern)
+	case *Class:
+		return s.compile(e.Pattern)
+	case *Ref:
+		if _, ok := s.rules[e.Name]; ok {
+			return nil
+		}
+		if _, ok := s.opts.Terminals[e.Name]; ok {
+			return nil
+		}
+		return fmt.Errorf("undefined `%s`", e.Name)
+	}
+	return nil
+}
+
+func (s *Sampler) checkAll(xs []Expr) error {
+	for _, x := range xs {
+		if err := s.check(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sampler) compile(pattern string) error {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return err
+	}
+	s.regexes[pattern] = re.Simplify()
+	return nil
+}
+
+// minCost 生成 e 最少需要的规则嵌套层数
+func (s *Sampler) minCost(e Expr) int {
+	switch e := e.(type) {
+	case *Seq:
+		c := 0
+		for _, x := range e.Xs {
+			if xc := s.minCost(x); xc > c {
+				c = xc
+			}
+		}
+		return c
+	case *Choice:
+		c := math.MaxInt32
+		for _, x := range e.Xs {
+			if xc := s.minCost(x); xc < c {
+				c = xc
+			}
+		}
+		return c
+	case *Repeat:
+		if e.Min == 0 {
+			return 0
+		}
+		return s.minCost(e.X)
+	case *Ref:
+		if c, ok := s.cost[e.Name]; ok {
+			if c == math.MaxInt32 {
+				return c
+			}
+			return c + 1
+		}
+		return 0 // 终结符
+	default:
+		return 0 // Opt And Not 以及终结符
+	}
+}
+
+func (s *Sampler) gen(b *strings.Builder, rnd *rand.Rand, e Expr, depth int) {
+	bounded := depth >= s.opts.MaxDepth
+	switch e := e.(type) {
+	case *Seq:
+		for _, x := range e.Xs {
+			s.gen(b, rnd, x, depth)
+		}
+	case *Choice:
+		s.gen(b, rnd, e.Xs[s.choose(rnd, e, bounded)], depth)
+	case *Repeat:
+		n := e.Min
+		if !bounded {
+			n += rnd.Intn(s.opts.MaxRepeat + 1 - e.Min)
+		}
+		for i := 0; i < n; i++ {
+			s.gen(b, rnd, e.X, depth)
+		}
+	case *Opt:
+		if !bounded && rnd.Intn(2) == 0 {
+			s.gen(b, rnd, e.X, depth)
+		}
+	case *And, *Not:
+		// 谓词不生成输入
+	case *Lit:
+		if e.Fold {
+			for _, r := range e.Text {
+				b.WriteRune(randomCase(rnd, r))
+			}
+		} else {
+			b.WriteString(e.Text)
+		}
+		b.WriteString(s.opts.Sep)
+	case *Regex:
+		s.regex(b, rnd, e.Pattern)
+	case *Class:
+		s.regex(b, rnd, e.Pattern)
+	case *Any:
+		b.WriteRune(printable(rnd))
+		b.WriteString(s.opts.Sep)
+	case *Ref:
+		if r, ok := s.rules[e.Name]; ok {
+			s.gen(b, rnd, r.Expr, depth+1)
+		} else {
+			b.WriteString(s.opts.Terminals[e.Name](rnd))
+			b.WriteString(s.opts.Sep)
+		}
+	}
+}
+
+// choose 超过深度时选择最快结束的分支, 否则按权重随机选择
+func (s *Sampler) choose(rnd *rand.Rand, c *Choice, bounded bool) int {
+	if bounded {
+		best, cost := 0, math.MaxInt32
+		for i, x := range c.Xs {
+			if xc := s.minCost(x); xc < cost {
+				best, cost = i, xc
+			}
+		}
+		return best
+	}
+	ws, ok := s.weights[c]
+	if !ok {
+		return rnd.Intn(len(c.Xs))
+	}
+	total := 0
+	for _, w := range ws {
+		total += w
+	}
+	if total <= 0 {
+		return rnd.Intn(len(c.Xs))
+	}
+	n := rnd.Intn(total)
+	for i, w := range ws {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return len(ws) - 1
+}
+
+// regex 按正则生成非空的匹配, Regex 原语不接受空匹配
+func (s *Sampler) regex(b *strings.Builder, rnd *rand.Rand, pattern string) {
+	re := s.regexes[pattern]
+	var rb strings.Builder
+	for i := 0; i < 8 && rb.Len() == 0; i++ {
+		s.sampleRegex(&rb, rnd, re)
+	}
+	b.WriteString(rb.String())
+	b.WriteString(s.opts.Sep)
+}
+
+func (s *Sampler) sampleRegex(b *strings.Builder, rnd *rand.Rand, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				r = randomCase(rnd, r)
+			}
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		b.WriteRune(sampleClass(rnd, re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		b.WriteRune(printable(rnd))
+	case syntax.OpCapture:
+		s.sampleRegex(b, rnd, re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, s.opts.MaxRepeat
+		case syntax.OpPlus:
+			min, max = 1, s.opts.MaxRepeat
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + s.opts.MaxRepeat
+		}
+		for i, n := 0, min+rnd.Intn(max-min+1); i < n; i++ {
+			s.sampleRegex(b, rnd, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			s.sampleRegex(b, rnd, sub)
+		}
+	case syntax.OpAlternate:
+		s.sampleRegex(b, rnd, re.Sub[rnd.Intn(len(re.Sub))])
+	default:
+		// 空匹配, 行首行尾, 单词边界等不生成输入
+	}
+}
+
+// sampleClass 字符类包含可打印 ascii 时大多从中选择, 避免生成大量生僻字符
+func sampleClass(rnd *rand.Rand, ranges []rune) rune {
+	if len(ranges) == 0 {
+		return unicode.ReplacementChar
+	}
+	if rnd.Intn(10) > 0 {
+		var ascii []rune
+		for i := 0; i < len(ranges); i += 2 {
+			for r := maxRune(ranges[i], ' '); r <= ranges[i+1] && r <= '~'; r++ {
+				ascii = append(ascii, r)
+			}
+		}
+		if len(ascii) > 0 {
+			return ascii[rnd.Intn(len(ascii))]
+		}
+	}
+	i := rnd.Intn(len(ranges)/2) * 2
+	lo, hi := ranges[i], ranges[i+1]
+	r := lo + rune(rnd.Int63n(int64(hi-lo)+1))
+	if r >= 0xD800 && r <= 0xDFFF { // 代理区的 rune 不能编码
+		return lo
+	}
+	return r
+}
+
+func maxRune(a, b rune) rune {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func printable(rnd *rand.Rand) rune { return rune(' ' + rnd.Intn('~'-' '+1)) }
+
+func randomCase(rnd *rand.Rand, r rune) rune {
+	if rnd.Intn(2) == 0 {
+		return unicode.ToUpper(r)
+	}
+	return unicode.ToLower(r)
+}
+
+// Check 生成 n 个句子交给 parse, 返回第一个解析失败或者 panic 的句子的错误
+func (s *Sampler) Check(rnd *rand.Rand, n int, parse func(string) error) error {
+	for i := 0; i < n; i++ {
+		in := s.Sample(rnd)
+		if err := safeParse(parse, in); err != nil {
+			return fmt.Errorf("sentence %q: %s", in, err)
+		}
+	}
+	return nil
+}
+
+func safeParse(parse func(string) error, in string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return parse(in)
+}
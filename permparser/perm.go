@@ -0,0 +1,114 @@
+package permparser
+
+import (
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// 参考 Text.Parsec.Perm
+// permute (f <$$> p1 <||> p2 <|?> (x, p3))
+// 每个组件以任意顺序出现且只出现一次, 结果按声明顺序返回 []any
+
+func NewPerm() *Perm { return &Perm{} }
+
+// Perm 排列短语 parser
+// e.g. NewPerm().Required("name", name).Required("version", version).Optional("tags", tags, nil)
+type Perm struct {
+	items []permItem
+}
+
+type permItem struct {
+	name     string
+	p        Parser
+	optional bool
+	x        interface{} // optional 组件缺失时的默认值
+}
+
+// Required 必须出现一次的组件, name 用于错误信息
+func (pm *Perm) Required(name string, p Parser) *Perm {
+	pm.items = append(pm.items, permItem{name: name, p: p})
+	return pm
+}
+
+// Optional 最多出现一次的组件, 缺失时返回默认值 x
+func (pm *Perm) Optional(name string, p Parser, x interface{}) *Perm {
+	pm.items = append(pm.items, permItem{name: name, p: p, optional: true, x: x})
+	return pm
+}
+
+func (pm *Perm) Map(f func(v interface{}) interface{}) Parser { return Map(pm, f) }
+func (pm *Perm) FlatMap(f func(v interface{}) Parser) Parser  { return FlatMap(pm, f) }
+
+// Parse 每轮按声明顺序尝试未匹配的组件 (自动 Try), 直到都不匹配
+// 不消费 state 的成功 (e.g. Option Many) 不算匹配, 只在组件最终未出现时作为它的结果
+// 1. 已匹配的组件再次出现并消费 state, 报 duplicate 错误
+// 2. 必选组件缺失, 优先报告消费最远的组件错误, 否则报 missing 错误
+func (pm *Perm) Parse(s State) (interface{}, error) {
+	xs := make([]interface{}, len(pm.items))
+	matched := make([]bool, len(pm.items))
+	empty := make([]bool, len(pm.items)) // 不消费 state 时成功, 结果暂存在 xs
+	var furthest error
+	var furthestIdx = -1
+
+	for n := 0; n < len(pm.items); {
+		found := false
+		for i, it := range pm.items {
+			if matched[i] {
+				continue
+			}
+			start := s.Save()
+			v, err := Try(it.p).Parse(s)
+			if err == nil && s.Save().Idx == start.Idx {
+				s.Restore(start)
+				xs[i], empty[i] = v, true
+				continue
+			}
+			if err == nil {
+				xs[i] = v
+				matched[i] = true
+				found = true
+				n++
+				break
+			}
+			if e, ok := err.(Error); ok && e.Idx > furthestIdx {
+				furthest, furthestIdx = err, e.Idx
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	pos := s.Save()
+	for i, it := range pm.items {
+		if !matched[i] {
+			continue
+		}
+		_, err := Try(it.p).Parse(s)
+		consumed := s.Save().Idx != pos.Idx
+		s.Restore(pos)
+		if err == nil && consumed {
+			return nil, Trap(pos, "duplicate `%s`", it.name)
+		}
+	}
+
+	var missing []string
+	for i, it := range pm.items {
+		if matched[i] || empty[i] {
+			continue
+		}
+		if it.optional {
+			xs[i] = it.x
+		} else {
+			missing = append(missing, "`"+it.name+"`")
+		}
+	}
+	if len(missing) > 0 {
+		if furthest != nil && furthestIdx > pos.Idx {
+			return nil, furthest
+		}
+		return nil, Trap(pos, "missing %s", strings.Join(missing, ", "))
+	}
+	return xs, nil
+}
@@ -16,6 +16,10 @@ func Satisfy(f func(interface{}) bool, expect string) Parser {
 		pos := s.Save()
 		nxt, ok := s.Next()
 		if !ok {
+			// State 在 Next 时中止了解析 (e.g. tokstate.LazyState 的词法错误), 不是输入结束
+			if c := ContextOf(s); c != nil && c.abort != nil {
+				return nil, c.abort
+			}
 			return nxt, Trap(pos, "expect `%s` actual end of input", expect)
 		}
 		if !f(nxt) {
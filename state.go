@@ -10,6 +10,25 @@ type State interface {
 	Get() interface{}
 }
 
+// Lossless 可以取回原始输入的 State, 用于 CST 等需要原样输出源码的场景
+// 相邻范围首尾相接: Slice(a, b) + Slice(b, c) == Slice(a, c), Slice(初始位置, End()) 为完整输入
+type Lossless interface {
+	Slice(from, to Pos) string
+	End() Pos
+}
+
+// Tokenized Lossless 可选实现, 把 Slice(from, to) 按 token 边界切分, 依次拼接与 Slice(from, to) 相同
+type Tokenized interface {
+	Pieces(from, to Pos) []Piece
+}
+
+// Piece 一个 token 或者 trivia (空白 注释等) 的源码与范围
+type Piece struct {
+	Text   string
+	Trivia bool
+	Span
+}
+
 type Error struct {
 	Pos
 	Msg string
@@ -23,6 +42,34 @@ type Pos struct {
 	Line int
 }
 
+// NoLine 二进制输入没有行列, Line 为 NoLine 的 Pos 只显示字节偏移
+const NoLine = -1
+
+// BitLine 按 bit 读取的输入 (bitstate), Line 为 BitLine 的 Pos 的 Idx 为 bit 偏移
+const BitLine = -2
+
 func (p Pos) String() string {
+	switch p.Line {
+	case NoLine:
+		return fmt.Sprintf("offset %d (0x%x)", p.Idx, p.Idx)
+	case BitLine:
+		return fmt.Sprintf("bit %d (byte %d + %d)", p.Idx, p.Idx/8, p.Idx%8)
+	}
 	return fmt.Sprintf("pos %d line %d col %d", p.Idx+1, p.Line+1, p.Col+1)
 }
+
+// Span [Start, End)
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+func (s Span) String() string {
+	switch s.Start.Line {
+	case NoLine:
+		return fmt.Sprintf("offset %d-%d", s.Start.Idx, s.End.Idx)
+	case BitLine:
+		return fmt.Sprintf("bit %d-%d", s.Start.Idx, s.End.Idx)
+	}
+	return fmt.Sprintf("pos %d-%d line %d col %d", s.Start.Idx+1, s.End.Idx+1, s.Start.Line+1, s.Start.Col+1)
+}
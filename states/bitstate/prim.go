@@ -0,0 +1,88 @@
+package bitstate
+
+import (
+	"strconv"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Primitive Bit Parsers
+// ----------------------------------------------------------------
+
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	AnyBit = BitSatisfy(func(b Bit) bool { return true }, "bit")
+	Zero   = BitSatisfy(func(b Bit) bool { return b == 0 }, "0")
+	One    = BitSatisfy(func(b Bit) bool { return b == 1 }, "1")
+
+	// Flag 读取 1 bit, 返回 bool
+	Flag = AnyBit.Map(func(v interface{}) interface{} { return v.(Bit) == 1 })
+
+	// AlignByte 跳过剩余的 bit, 对齐到下一个字节边界
+	AlignByte = NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*BitState)
+		if !s.Aligned() {
+			s.Idx += 8 - s.Idx%8
+		}
+		return nil, nil
+	})
+)
+
+func BitSatisfy(pred func(Bit) bool, expect string) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*BitState)
+		pos := s.Save()
+		if !s.eof() && pred(s.bit(s.Idx)) {
+			s.Idx++
+			return s.bit(pos.Idx), nil
+		}
+		return nil, s.trapExpect(pos, expect)
+	})
+}
+
+// Bits 读取 n (<= 64) 个 bit, 返回 uint64
+// MSB first 时先读到的 bit 为高位, LSB first 时先读到的 bit 为低位
+func Bits(n int) Parser {
+	if n < 0 || n > 64 {
+		panic("Bits: n must be in [0, 64]")
+	}
+	expect := strconv.Itoa(n) + " bits"
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*BitState)
+		pos := s.Save()
+		if s.Idx+n > len(s.seq)*8 {
+			return nil, Trap(pos, "expect `%s` actual end of input", expect)
+		}
+		var v uint64
+		for i := 0; i < n; i++ {
+			b := uint64(s.bit(s.Idx))
+			if s.lsb {
+				v |= b << i
+			} else {
+				v = v<<1 | b
+			}
+			s.Idx++
+		}
+		return v, nil
+	})
+}
+
+// Bytes 在字节边界上读取 n 个完整的字节, 返回 []byte (复制)
+// 未对齐时失败, 需要先 AlignByte
+func Bytes(n int) Parser {
+	expect := strconv.Itoa(n) + " bytes"
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*BitState)
+		pos := s.Save()
+		if !s.Aligned() {
+			return nil, Trap(pos, "expect byte aligned before `%s`", expect)
+		}
+		off := s.Idx / 8
+		if n < 0 || off+n > len(s.seq) {
+			return nil, Trap(pos, "expect `%s` actual end of input", expect)
+		}
+		s.Idx += n * 8
+		return append([]byte{}, s.seq[off:off+n]...), nil
+	})
+}
@@ -0,0 +1,70 @@
+package bitstate
+
+import (
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Bit State
+// Next 每次产出一个 Bit, Pos.Idx 为 bit 偏移, Line 为 BitLine
+// ----------------------------------------------------------------
+
+// NewState 每个字节从高位到低位读取 (MSB first), e.g. 网络协议头
+func NewState(b []byte) State {
+	return &BitState{seq: b, Pos: Pos{Line: BitLine}}
+}
+
+// NewStateLSB 每个字节从低位到高位读取 (LSB first), e.g. deflate
+func NewStateLSB(b []byte) State {
+	return &BitState{seq: b, lsb: true, Pos: Pos{Line: BitLine}}
+}
+
+type Bit uint8
+
+func (b Bit) String() string {
+	if b == 0 {
+		return "0"
+	}
+	return "1"
+}
+
+type BitState struct {
+	seq []byte
+	lsb bool
+	Pos
+	ud interface{}
+	ContextHolder
+}
+
+func (s *BitState) Save() Pos     { return s.Pos }
+func (s *BitState) Restore(l Pos) { s.Pos = l }
+func (s *BitState) Next() (interface{}, bool) {
+	if s.eof() {
+		return nil, false
+	}
+	b := s.bit(s.Idx)
+	s.Idx++
+	return b, true
+}
+
+func (s *BitState) eof() bool { return s.Idx >= len(s.seq)*8 }
+func (s *BitState) bit(i int) Bit {
+	b := s.seq[i/8]
+	if s.lsb {
+		return Bit(b >> (i % 8) & 1)
+	}
+	return Bit(b >> (7 - i%8) & 1)
+}
+
+// Aligned 当前位置是否在字节边界上
+func (s *BitState) Aligned() bool { return s.Idx%8 == 0 }
+
+func (s *BitState) trapExpect(pos Pos, expect string) error {
+	if pos.Idx >= len(s.seq)*8 {
+		return Trap(pos, "expect `%s` actual end of input", expect)
+	}
+	return Trap(pos, "expect `%s` actual `%s`", expect, s.bit(pos.Idx))
+}
+
+func (s *BitState) Put(ud interface{}) { s.ud = ud }
+func (s *BitState) Get() interface{}   { return s.ud }
@@ -0,0 +1,202 @@
+package bytestate
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Binary Parsers
+// 配合 NewBinaryState 解析二进制协议, 整数按宽度返回对应类型, e.g. U16LE 返回 uint16
+// ----------------------------------------------------------------
+
+var le, be = binary.LittleEndian, binary.BigEndian
+
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	U8    = fixed("u8", 1, func(b []byte) interface{} { return b[0] })
+	U16LE = fixed("u16le", 2, func(b []byte) interface{} { return le.Uint16(b) })
+	U16BE = fixed("u16be", 2, func(b []byte) interface{} { return be.Uint16(b) })
+	U32LE = fixed("u32le", 4, func(b []byte) interface{} { return le.Uint32(b) })
+	U32BE = fixed("u32be", 4, func(b []byte) interface{} { return be.Uint32(b) })
+	U64LE = fixed("u64le", 8, func(b []byte) interface{} { return le.Uint64(b) })
+	U64BE = fixed("u64be", 8, func(b []byte) interface{} { return be.Uint64(b) })
+
+	I8    = fixed("i8", 1, func(b []byte) interface{} { return int8(b[0]) })
+	I16LE = fixed("i16le", 2, func(b []byte) interface{} { return int16(le.Uint16(b)) })
+	I16BE = fixed("i16be", 2, func(b []byte) interface{} { return int16(be.Uint16(b)) })
+	I32LE = fixed("i32le", 4, func(b []byte) interface{} { return int32(le.Uint32(b)) })
+	I32BE = fixed("i32be", 4, func(b []byte) interface{} { return int32(be.Uint32(b)) })
+	I64LE = fixed("i64le", 8, func(b []byte) interface{} { return int64(le.Uint64(b)) })
+	I64BE = fixed("i64be", 8, func(b []byte) interface{} { return int64(be.Uint64(b)) })
+
+	Float32LE = fixed("f32le", 4, func(b []byte) interface{} { return math.Float32frombits(le.Uint32(b)) })
+	Float32BE = fixed("f32be", 4, func(b []byte) interface{} { return math.Float32frombits(be.Uint32(b)) })
+	Float64LE = fixed("f64le", 8, func(b []byte) interface{} { return math.Float64frombits(le.Uint64(b)) })
+	Float64BE = fixed("f64be", 8, func(b []byte) interface{} { return math.Float64frombits(be.Uint64(b)) })
+
+	// Uvarint protobuf varint / unsigned LEB128, 返回 uint64
+	Uvarint = varint("uvarint", func(b []byte) (interface{}, int) { return binary.Uvarint(b) })
+	// Varint zigzag 编码的有符号 varint (protobuf sint64), 返回 int64
+	Varint = varint("varint", func(b []byte) (interface{}, int) { return binary.Varint(b) })
+)
+
+// Bytes 读取 n 个字节, 返回 []byte (复制)
+func Bytes(n int) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		b, err := s.take("bytes", n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	})
+}
+
+// Magic 匹配固定的字节序列, e.g. Magic([]byte("\x89PNG\r\n\x1a\n"))
+func Magic(magic []byte) Parser {
+	magic = append([]byte{}, magic...)
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		pos := s.Save()
+		rest := s.seq[s.Idx:]
+		if len(rest) < len(magic) || string(rest[:len(magic)]) != string(magic) {
+			if len(rest) > len(magic) {
+				rest = rest[:len(magic)]
+			}
+			return nil, Trap(pos, "expect magic `% x` actual `% x`", magic, rest)
+		}
+		for _, b := range magic {
+			s.forward(b)
+		}
+		return magic, nil
+	})
+}
+
+// LengthPrefixed 先用 lenParser 解析长度 n (任意整数类型), 再用 p 解析之后的 n 个字节
+// p 必须恰好消费 n 个字节, p 为 nil 时直接返回这 n 个字节
+// p 在新的 Memo 作用域中执行 (见 Context.MemoScope), 截断输入得到的 Memo 结果不会在窗口外复用
+// e.g. LengthPrefixed(Uvarint, nil) 解析 protobuf 的 length-delimited 字段
+func LengthPrefixed(lenParser, p Parser) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		pos := s.Save()
+		v, err := lenParser.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := toInt(v)
+		if !ok || n < 0 {
+			return nil, Trap(pos, "invalid length %v", v)
+		}
+		if p == nil {
+			return Bytes(n).Parse(s)
+		}
+		// 先比较剩余长度, s.Idx + n 可能溢出
+		if n > len(s.seq)-s.Idx {
+			return nil, Trap(s.Save(), "expect %d bytes actual end of input", n)
+		}
+		end := s.Idx + n
+		// 把输入截断到 end, p 看不到之后的字节
+		seq := s.seq
+		s.seq = seq[:end]
+		if c := ContextOf(s); c != nil {
+			c.MemoScope(func() { v, err = p.Parse(s) })
+		} else {
+			v, err = p.Parse(s)
+		}
+		s.seq = seq
+		if err != nil {
+			return nil, err
+		}
+		if s.Idx != end {
+			return nil, Trap(s.Save(), "expect end of %d bytes block", n)
+		}
+		return v, nil
+	})
+}
+
+// Align 跳过填充字节, 直到偏移量是 n 的整数倍, n 必须大于 0
+func Align(n int) Parser {
+	if n <= 0 {
+		panic("Align: n must be > 0")
+	}
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		if pad := (n - s.Idx%n) % n; pad > 0 {
+			if _, err := s.take("align "+strconv.Itoa(n), pad); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+}
+
+func fixed(name string, n int, f func([]byte) interface{}) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		b, err := s.take(name, n)
+		if err != nil {
+			return nil, err
+		}
+		return f(b), nil
+	})
+}
+
+func varint(name string, f func([]byte) (interface{}, int)) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		pos := s.Save()
+		v, n := f(s.seq[s.Idx:])
+		if n == 0 {
+			return nil, Trap(pos, "expect `%s` actual end of input", name)
+		}
+		if n < 0 {
+			return nil, Trap(pos, "%s overflows 64 bits", name)
+		}
+		_, _ = s.take(name, n)
+		return v, nil
+	})
+}
+
+// take 读取 n 个字节, 返回的切片引用输入
+func (s *ByteState) take(expect string, n int) ([]byte, error) {
+	if n < 0 || len(s.seq)-s.Idx < n {
+		return nil, Trap(s.Save(), "expect `%s` actual end of input", expect)
+	}
+	b := s.seq[s.Idx : s.Idx+n]
+	for _, c := range b {
+		s.forward(c)
+	}
+	return b, nil
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), int64(int(n)) == n
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), int(n) >= 0
+	case uint64:
+		return int(n), int(n) >= 0 && uint64(int(n)) == n
+	case uint:
+		return int(n), int(n) >= 0
+	default:
+		return 0, false
+	}
+}
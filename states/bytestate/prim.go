@@ -44,6 +44,13 @@ var (
 	Ident    = Regex(lexer.RegIdent) // 支持 unicode
 )
 
+// alias
+//
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	RegexGroups = RegexSubmatch
+)
+
 func OneOf(bytes string) Parser  { return ByteSatisfy(oneOf(bytes), "one of '"+bytes+"'") }
 func NoneOf(bytes string) Parser { return ByteSatisfy(noneOf(bytes), "none of '"+bytes+"'") }
 
@@ -75,20 +82,104 @@ func Str(str string) Parser {
 	})
 }
 
+// ----------------------------------------------------------------
+// Case-insensitive & ASCII
+// ----------------------------------------------------------------
+
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	IdentStart    = ByteSatisfy(IsIdentStart, "ident start")
+	IdentContinue = ByteSatisfy(IsIdentContinue, "ident continue")
+)
+
+// StrFold 忽略 ASCII 大小写匹配 str, 返回实际匹配到的输入
+func StrFold(str string) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*ByteState)
+		from := s.Idx
+		for _, c := range []byte(str) {
+			r, ok := s.NextIf(foldEquals(c))
+			if !ok {
+				return nil, s.trapExpect(s.Save(), string(c), r)
+			}
+		}
+		return string(s.seq[from:s.Idx]), nil
+	})
+}
+
+func CharFold(b byte) Parser { return ByteSatisfy(foldEquals(b), string(b)) }
+func OneOfFold(bytes string) Parser {
+	return ByteSatisfy(oneOfFold(bytes), "one of '"+bytes+"' ignore case")
+}
+
+// InRange 匹配 [lo, hi] 区间内的 byte
+func InRange(lo, hi byte) Parser {
+	return ByteSatisfy(func(b byte) bool { return b >= lo && b <= hi }, string(lo)+"-"+string(hi))
+}
+
+// IsIdentStart [A-Za-z_]
+func IsIdentStart(b byte) bool { return b == '_' || 'a' <= lower(b) && lower(b) <= 'z' }
+
+// IsIdentContinue [A-Za-z0-9_]
+func IsIdentContinue(b byte) bool { return IsIdentStart(b) || b >= '0' && b <= '9' }
+
+// Regex 在原始输入上从当前位置匹配 reg, 返回匹配的字符串
+// 不匹配或者匹配空串都会失败
 func Regex(reg string) Parser {
+	return regex(reg, false, func(_ *regexp.Regexp, rest []byte, loc []int) interface{} { return string(rest[:loc[1]]) })
+}
+
+// RegexSubmatch 同 Regex, 返回 []string, 0 为整个匹配, 之后依次为各分组, 未参与匹配的分组为空串
+// e.g. RegexSubmatch(`(\d{4})-(\d{2})-(\d{2})`) => [2023-01-02 2023 01 02]
+func RegexSubmatch(reg string) Parser {
+	return regex(reg, true, func(_ *regexp.Regexp, rest []byte, loc []int) interface{} {
+		xs := make([]string, len(loc)/2)
+		for i := range xs {
+			if loc[2*i] >= 0 {
+				xs[i] = string(rest[loc[2*i]:loc[2*i+1]])
+			}
+		}
+		return xs
+	})
+}
+
+// RegexNamed 同 Regex, 返回命名分组 map[string]string, 未参与匹配的分组为空串
+// e.g. RegexNamed(`(?P<key>\w+)=(?P<val>\w+)`) => map[key:k val:v]
+func RegexNamed(reg string) Parser {
+	return regex(reg, true, func(patten *regexp.Regexp, rest []byte, loc []int) interface{} {
+		m := map[string]string{}
+		for i, name := range patten.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			m[name] = ""
+			if loc[2*i] >= 0 {
+				m[name] = string(rest[loc[2*i]:loc[2*i+1]])
+			}
+		}
+		return m
+	})
+}
+
+func regex(reg string, submatch bool, result func(patten *regexp.Regexp, rest []byte, loc []int) interface{}) Parser {
 	patten := regexp.MustCompile("^(?:" + reg + ")")
 	return NewParser(func(s_ State) (interface{}, error) {
 		s := s_.(*ByteState)
 		pos := s.Save()
-		found := patten.FindString(string(s.seq[s.Idx:]))
-		if found == "" {
-			return nil, Trap(pos, "expect pattern '%s'", reg)
+		rest := s.seq[s.Idx:]
+		var loc []int
+		if submatch {
+			loc = patten.FindSubmatchIndex(rest)
 		} else {
-			for _, b := range []byte(found) {
-				s.forward(b)
-			}
-			return found, nil
+			loc = patten.FindIndex(rest)
+		}
+		if loc == nil || loc[1] == 0 {
+			return nil, Trap(pos, "expect pattern '%s'", reg)
+		}
+		for _, b := range rest[:loc[1]] {
+			s.forward(b)
 		}
+		return result(patten, rest, loc), nil
 	})
 }
 
@@ -103,3 +194,22 @@ func constTrue(b byte) bool        { return true }
 func equals(a byte) bytePred       { return func(b byte) bool { return a == b } }
 func oneOf(bytes string) bytePred  { return func(r byte) bool { return indexOf(bytes, r) >= 0 } }
 func noneOf(bytes string) bytePred { return func(r byte) bool { return indexOf(bytes, r) < 0 } }
+
+func foldEquals(a byte) bytePred { return func(b byte) bool { return lower(a) == lower(b) } }
+func oneOfFold(bytes string) bytePred {
+	return func(b byte) bool {
+		for i := 0; i < len(bytes); i++ {
+			if lower(bytes[i]) == lower(b) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func lower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
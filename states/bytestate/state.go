@@ -12,12 +12,19 @@ func NewState(s string) State {
 	return &ByteState{seq: []byte(s)}
 }
 
+// NewBinaryState 二进制输入, 不区分行, Pos 只记录字节偏移 (Line 为 NoLine)
+func NewBinaryState(b []byte) State {
+	return &ByteState{seq: b, Pos: Pos{Line: NoLine}, binary: true}
+}
+
 const eof byte = 0
 
 type ByteState struct {
-	seq []byte
+	seq    []byte
+	binary bool
 	Pos
 	ud interface{}
+	ContextHolder
 }
 
 func (s *ByteState) Save() Pos                 { return s.Pos }
@@ -37,6 +44,9 @@ func (s *ByteState) NextIf(pred func(byte) bool) (byte, bool) {
 }
 func (s *ByteState) forward(b byte) {
 	s.Idx++
+	if s.binary {
+		return
+	}
 	if b == '\n' {
 		s.Line++
 		s.Col = 0
@@ -45,11 +55,25 @@ func (s *ByteState) forward(b byte) {
 	}
 }
 func (s *ByteState) trapExpect(pos Pos, expect string, actual byte) error {
-	if actual == eof {
+	if pos.Idx >= len(s.seq) {
 		return Trap(pos, "expect `%s` actual end of input", expect)
+	} else if s.binary {
+		return Trap(pos, "expect `%s` actual 0x%02x", expect, actual)
 	} else {
 		return Trap(pos, "expect `%s` actual `%s`", expect, string(actual))
 	}
 }
+func (s *ByteState) Slice(from, to Pos) string { return string(s.seq[from.Idx:to.Idx]) }
+func (s *ByteState) End() Pos {
+	if s.binary {
+		return Pos{Idx: len(s.seq), Line: NoLine}
+	}
+	t := ByteState{}
+	for _, b := range s.seq {
+		t.forward(b)
+	}
+	return t.Pos
+}
+
 func (s *ByteState) Put(ud interface{}) { s.ud = ud }
 func (s *ByteState) Get() interface{}   { return s.ud }
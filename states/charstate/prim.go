@@ -44,6 +44,13 @@ var (
 	Ident    = Regex(lexer.RegIdent) // 支持 unicode
 )
 
+// alias
+//
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	RegexGroups = RegexSubmatch
+)
+
 func OneOf(runes string) Parser  { return CharSatisfy(oneOf(runes), "one of '"+runes+"'") }
 func NoneOf(runes string) Parser { return CharSatisfy(noneOf(runes), "none of '"+runes+"'") }
 
@@ -75,20 +82,123 @@ func Str(str string) Parser {
 	})
 }
 
+// ----------------------------------------------------------------
+// Case-insensitive & Unicode
+// ----------------------------------------------------------------
+
+//goland:noinspection GoUnusedGlobalVariable
+var (
+	IdentStart    = CharSatisfy(IsIdentStart, "ident start")
+	IdentContinue = CharSatisfy(IsIdentContinue, "ident continue")
+)
+
+// StrFold 按 unicode simple folding 忽略大小写匹配 str, 返回实际匹配到的输入
+// e.g. StrFold("select") 可以匹配 "SELECT" "Select"
+func StrFold(str string) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*CharState)
+		from := s.Idx
+		for _, c := range str {
+			r, ok := s.NextIf(foldEquals(c))
+			if !ok {
+				return nil, s.trapExpect(s.Save(), string(c), r)
+			}
+		}
+		return string(s.seq[from:s.Idx]), nil
+	})
+}
+
+func CharFold(r rune) Parser { return CharSatisfy(foldEquals(r), string(r)) }
+func OneOfFold(runes string) Parser {
+	return CharSatisfy(oneOfFold(runes), "one of '"+runes+"' ignore case")
+}
+
+// InRange 匹配 [lo, hi] 区间内的 rune
+func InRange(lo, hi rune) Parser {
+	return CharSatisfy(func(r rune) bool { return r >= lo && r <= hi }, string(lo)+"-"+string(hi))
+}
+
+// InTable 匹配 unicode.RangeTable 中的 rune, e.g. InTable(unicode.Han)
+func InTable(tab *unicode.RangeTable) Parser {
+	return CharSatisfy(func(r rune) bool { return unicode.Is(tab, r) }, tableName(tab))
+}
+
+// IsIdentStart UAX #31 ID_Start
+// L + Nl + Other_ID_Start - Pattern_Syntax - Pattern_White_Space
+// 注意不包含 '_', 需要的话 Alt(IdentStart, Char('_'))
+func IsIdentStart(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) || unicode.Is(unicode.Other_ID_Start, r)) &&
+		!isPattern(r)
+}
+
+// IsIdentContinue UAX #31 ID_Continue
+// ID_Start + Mn + Mc + Nd + Pc + Other_ID_Continue - Pattern_Syntax - Pattern_White_Space
+func IsIdentContinue(r rune) bool {
+	return (IsIdentStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)) &&
+		!isPattern(r)
+}
+
+func isPattern(r rune) bool {
+	return unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// Regex 在原始输入上从当前位置匹配 reg, 返回匹配的字符串
+// 不匹配或者匹配空串都会失败
 func Regex(reg string) Parser {
+	return regex(reg, false, func(_ *regexp.Regexp, rest string, loc []int) interface{} { return rest[:loc[1]] })
+}
+
+// RegexSubmatch 同 Regex, 返回 []string, 0 为整个匹配, 之后依次为各分组, 未参与匹配的分组为空串
+// e.g. RegexSubmatch(`(\d{4})-(\d{2})-(\d{2})`) => [2023-01-02 2023 01 02]
+func RegexSubmatch(reg string) Parser {
+	return regex(reg, true, func(_ *regexp.Regexp, rest string, loc []int) interface{} {
+		xs := make([]string, len(loc)/2)
+		for i := range xs {
+			if loc[2*i] >= 0 {
+				xs[i] = rest[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		return xs
+	})
+}
+
+// RegexNamed 同 Regex, 返回命名分组 map[string]string, 未参与匹配的分组为空串
+// e.g. RegexNamed(`(?P<key>\w+)=(?P<val>\w+)`) => map[key:k val:v]
+func RegexNamed(reg string) Parser {
+	return regex(reg, true, func(patten *regexp.Regexp, rest string, loc []int) interface{} {
+		m := map[string]string{}
+		for i, name := range patten.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			m[name] = ""
+			if loc[2*i] >= 0 {
+				m[name] = rest[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		return m
+	})
+}
+
+func regex(reg string, submatch bool, result func(patten *regexp.Regexp, rest string, loc []int) interface{}) Parser {
 	patten := regexp.MustCompile("^(?:" + reg + ")")
 	return NewParser(func(s_ State) (interface{}, error) {
 		s := s_.(*CharState)
 		pos := s.Save()
-		found := patten.FindString(string(s.seq[s.Idx:]))
-		if found == "" {
-			return nil, Trap(pos, "expect pattern '%s'", reg)
+		rest := s.src[s.byteOffset(s.Idx):]
+		var loc []int
+		if submatch {
+			loc = patten.FindStringSubmatchIndex(rest)
 		} else {
-			for _, r := range found {
-				s.forward(r)
-			}
-			return found, nil
+			loc = patten.FindStringIndex(rest)
+		}
+		if loc == nil || loc[1] == 0 {
+			return nil, Trap(pos, "expect pattern '%s'", reg)
+		}
+		for _, r := range rest[:loc[1]] {
+			s.forward(r)
 		}
+		return result(patten, rest, loc), nil
 	})
 }
 
@@ -103,3 +213,40 @@ func constTrue(r rune) bool        { return true }
 func equals(a rune) runePred       { return func(b rune) bool { return a == b } }
 func oneOf(runes string) runePred  { return func(r rune) bool { return indexOf(runes, r) >= 0 } }
 func noneOf(runes string) runePred { return func(r rune) bool { return indexOf(runes, r) < 0 } }
+
+func foldEquals(a rune) runePred { return func(b rune) bool { return equalFold(a, b) } }
+func oneOfFold(runes string) runePred {
+	return func(r rune) bool {
+		for _, c := range runes {
+			if equalFold(c, r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// equalFold a b 在 unicode simple folding 下是否相等, e.g. k K \u212A(开尔文符号)
+func equalFold(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// tableName 在 unicode 包预定义的表中查找名字, 用于错误信息
+func tableName(tab *unicode.RangeTable) string {
+	for _, m := range []map[string]*unicode.RangeTable{unicode.Categories, unicode.Scripts, unicode.Properties} {
+		for name, t := range m {
+			if t == tab {
+				return name
+			}
+		}
+	}
+	return "unicode range table"
+}
@@ -9,15 +9,18 @@ import (
 // ----------------------------------------------------------------
 
 func NewState(s string) State {
-	return &CharState{seq: []rune(s)}
+	return &CharState{seq: []rune(s), src: s}
 }
 
 const eof rune = -1
 
 type CharState struct {
-	seq []rune
+	seq  []rune
+	src  string // 原始输入, Regex 直接在 src 上匹配, 避免复制剩余输入
+	offs []int  // rune 下标 => src 字节偏移, 非 ascii 输入使用 Regex 时才构建
 	Pos
 	ud interface{}
+	ContextHolder
 }
 
 func (s *CharState) Save() Pos                 { return s.Pos }
@@ -44,6 +47,21 @@ func (s *CharState) forward(r rune) {
 		s.Col++
 	}
 }
+
+// byteOffset rune 下标 idx 对应 src 中的字节偏移
+func (s *CharState) byteOffset(idx int) int {
+	if len(s.src) == len(s.seq) {
+		return idx // 每个 rune 都只占一个字节
+	}
+	if s.offs == nil {
+		s.offs = make([]int, 0, len(s.seq)+1)
+		for off := range s.src {
+			s.offs = append(s.offs, off)
+		}
+		s.offs = append(s.offs, len(s.src))
+	}
+	return s.offs[idx]
+}
 func (s *CharState) trapExpect(pos Pos, expect string, actual rune) error {
 	if actual == eof {
 		return Trap(pos, "expect `%s` actual end of input", expect)
@@ -51,5 +69,14 @@ func (s *CharState) trapExpect(pos Pos, expect string, actual rune) error {
 		return Trap(pos, "expect `%s` actual `%s`", expect, string(actual))
 	}
 }
+func (s *CharState) Slice(from, to Pos) string { return string(s.seq[from.Idx:to.Idx]) }
+func (s *CharState) End() Pos {
+	t := CharState{}
+	for _, r := range s.seq {
+		t.forward(r)
+	}
+	return t.Pos
+}
+
 func (s *CharState) Put(ud interface{}) { s.ud = ud }
 func (s *CharState) Get() interface{}   { return s.ud }
@@ -0,0 +1,81 @@
+package charstate
+
+import (
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Symbol Trie
+// 代替 Alt(Str("<="), Str("<"), ...), 不需要手动排序, 一次扫描完成最长匹配
+// ----------------------------------------------------------------
+
+// Symbols 最长匹配 syms 中的符号, 返回匹配的字符串
+// e.g. Symbols([]string{"<", "<=", "<<"}) 匹配 "<<=" 返回 "<<"
+func Symbols(syms []string) Parser { return trieParser("Symbols", syms, nil) }
+
+// Keywords 最长匹配 kws 中的关键字, 关键字之后的字符不能满足 identContinue
+// e.g. Keywords([]string{"in", "int"}, nil) 匹配 "int" 返回 "int", 不匹配 "into"
+// identContinue 为 nil 时使用 IsIdentContinue
+func Keywords(kws []string, identContinue func(rune) bool) Parser {
+	if identContinue == nil {
+		identContinue = IsIdentContinue
+	}
+	return trieParser("Keywords", kws, identContinue)
+}
+
+type trie struct {
+	next map[rune]*trie
+	end  bool
+}
+
+func (t *trie) add(word string) {
+	for _, r := range word {
+		if t.next == nil {
+			t.next = map[rune]*trie{}
+		}
+		n, ok := t.next[r]
+		if !ok {
+			n = &trie{}
+			t.next[r] = n
+		}
+		t = n
+	}
+	t.end = true
+}
+
+func trieParser(name string, words []string, boundary func(rune) bool) Parser {
+	root := &trie{}
+	for _, w := range words {
+		if w == "" {
+			panic(name + ": empty string")
+		}
+		root.add(w)
+	}
+	expect := "one of " + strings.Join(words, " ")
+
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*CharState)
+		pos := s.Save()
+		rest := s.seq[s.Idx:]
+		n, t := 0, root
+		for i := 0; i < len(rest) && t != nil; i++ {
+			t = t.next[rest[i]]
+			if t != nil && t.end && (boundary == nil || i+1 == len(rest) || !boundary(rest[i+1])) {
+				n = i + 1
+			}
+		}
+		if n == 0 {
+			actual := eof
+			if len(rest) > 0 {
+				actual = rest[0]
+			}
+			return nil, s.trapExpect(pos, expect, actual)
+		}
+		for _, r := range rest[:n] {
+			s.forward(r)
+		}
+		return string(rest[:n]), nil
+	})
+}
@@ -0,0 +1,68 @@
+package slicestate
+
+import (
+	"reflect"
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Primitive Item Parsers
+// 失败时不消耗输入
+// ----------------------------------------------------------------
+
+func ItemSatisfy(pred func(interface{}) bool, expect string) Parser {
+	return NewParser(func(s_ State) (interface{}, error) {
+		s := s_.(*SliceState)
+		pos := s.Save()
+		v, ok := s.NextIf(pred)
+		if ok {
+			return v, nil
+		}
+		return nil, s.trapExpect(pos, expect)
+	})
+}
+
+// Equal 匹配与 x 相等 (reflect.DeepEqual) 的元素
+func Equal(x interface{}) Parser { return ItemSatisfy(equals(x), Show(x)) }
+
+func OneOf(xs ...interface{}) Parser { return ItemSatisfy(oneOf(xs), "one of "+show(xs)) }
+func NoneOf(xs ...interface{}) Parser {
+	return ItemSatisfy(func(v interface{}) bool { return !oneOf(xs)(v) }, "none of "+show(xs))
+}
+
+// TypeOf 匹配与 x 类型相同的元素, e.g. TypeOf((*ast.Ident)(nil))
+func TypeOf(x interface{}) Parser {
+	t := reflect.TypeOf(x)
+	return ItemSatisfy(func(v interface{}) bool { return reflect.TypeOf(v) == t }, t.String())
+}
+
+// ----------------------------------------------------------------
+// Util
+// ----------------------------------------------------------------
+
+func constTrue(interface{}) bool { return true }
+
+func equals(x interface{}) func(interface{}) bool {
+	return func(v interface{}) bool { return reflect.DeepEqual(x, v) }
+}
+
+func oneOf(xs []interface{}) func(interface{}) bool {
+	return func(v interface{}) bool {
+		for _, x := range xs {
+			if reflect.DeepEqual(x, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func show(xs []interface{}) string {
+	strs := make([]string, len(xs))
+	for i, x := range xs {
+		strs[i] = Show(x)
+	}
+	return strings.Join(strs, " ")
+}
@@ -0,0 +1,70 @@
+package slicestate
+
+import (
+	. "github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Slice State
+// 任意元素序列 (AST 节点, 事件 ...), 复用所有组合子
+// ----------------------------------------------------------------
+
+// PosFunc 返回元素自带的行列, e.g. AST 节点记录的源码位置
+type PosFunc func(item interface{}) (line, col int)
+
+// NewState 没有位置信息, Col 为元素下标
+func NewState(items []interface{}) State {
+	return NewStateWithPos(items, nil)
+}
+
+// NewStateWithPos Pos.Idx 为元素下标, Line Col 为 posOf 返回的当前元素位置
+// 输入结束时保持最后一个元素的位置
+func NewStateWithPos(items []interface{}, posOf PosFunc) State {
+	s := &SliceState{seq: items, posOf: posOf}
+	s.locate()
+	return s
+}
+
+type SliceState struct {
+	seq   []interface{}
+	posOf PosFunc
+	Pos
+	ud interface{}
+	ContextHolder
+}
+
+func (s *SliceState) Save() Pos                 { return s.Pos }
+func (s *SliceState) Restore(l Pos)             { s.Pos = l }
+func (s *SliceState) Next() (interface{}, bool) { return s.NextIf(constTrue) }
+func (s *SliceState) NextIf(pred func(interface{}) bool) (interface{}, bool) {
+	if s.Idx >= len(s.seq) {
+		return nil, false
+	}
+	v := s.seq[s.Idx]
+	if pred(v) {
+		s.Idx++
+		s.locate()
+		return v, true
+	} else {
+		return v, false
+	}
+}
+
+// locate 更新 Line Col 为当前元素的位置
+func (s *SliceState) locate() {
+	if s.posOf == nil {
+		s.Col = s.Idx
+	} else if s.Idx < len(s.seq) {
+		s.Line, s.Col = s.posOf(s.seq[s.Idx])
+	}
+}
+
+func (s *SliceState) trapExpect(pos Pos, expect string) error {
+	if pos.Idx >= len(s.seq) {
+		return Trap(pos, "expect `%s` actual end of input", expect)
+	}
+	return Trap(pos, "expect `%s` actual `%s`", expect, Show(s.seq[pos.Idx]))
+}
+
+func (s *SliceState) Put(ud interface{}) { s.ud = ud }
+func (s *SliceState) Get() interface{}   { return s.ud }
@@ -0,0 +1,182 @@
+package tokstate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goghcrow/lexer"
+	"github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Lazy Token State
+// 解析时按需从 Lexer 拉取 token, 已切出的 token 缓存用于回溯
+// 通过 WithMode 在指定位置切换词法模式, 支持模板字符串 正则字面量等上下文相关的词法
+// ----------------------------------------------------------------
+
+// Mode 词法模式, 0 为默认模式
+type Mode int
+
+// Lexer 从 src 的 at 处 (Idx 为字节偏移) 按 mode 切出下一个 token, 跳过空白注释等
+// 返回 token 与其之后的位置, 输入结束时 tok 为 nil, 返回错误时 next 为出错的位置, 在该处中止解析
+type Lexer interface {
+	Lex(src string, at parsec.Pos, mode Mode) (tok *lexer.Token, next parsec.Pos, err error)
+}
+
+type LexerFunc func(src string, at parsec.Pos, mode Mode) (*lexer.Token, parsec.Pos, error)
+
+func (f LexerFunc) Lex(src string, at parsec.Pos, mode Mode) (*lexer.Token, parsec.Pos, error) {
+	return f(src, at, mode)
+}
+
+func NewLazyState(src string, l Lexer) parsec.State { return &LazyState{src: src, lex: l} }
+
+type LazyState struct {
+	src  string
+	lex  Lexer
+	mode Mode
+
+	// 缓存, toks[i] 以 modes[i] 模式切出, ends[i] 为其之后的位置
+	toks  []*lexer.Token
+	modes []Mode
+	ends  []parsec.Pos
+
+	err error
+
+	parsec.Pos
+	ud interface{}
+	parsec.ContextHolder
+}
+
+func (t *LazyState) Save() parsec.Pos     { return t.Pos }
+func (t *LazyState) Restore(l parsec.Pos) { t.Pos = l }
+func (t *LazyState) Next() (interface{}, bool) {
+	tok := t.peek()
+	if tok == nil {
+		return nil, false
+	}
+	t.Idx++
+	t.Col = tok.Col
+	t.Line = tok.Line
+	return tok, true
+}
+
+// peek 当前位置的 token, 缓存的 token 模式不同时从该位置重新切分, 并丢弃之后的缓存
+func (t *LazyState) peek() *lexer.Token {
+	i := t.Idx
+	if i < len(t.toks) && t.modes[i] == t.mode {
+		return t.toks[i]
+	}
+	if i < len(t.toks) {
+		t.toks, t.modes, t.ends = t.toks[:i], t.modes[:i], t.ends[:i]
+	}
+	var at parsec.Pos
+	if i > 0 {
+		at = t.ends[i-1]
+	}
+	tok, next, err := t.lex.Lex(t.src, at, t.mode)
+	if err != nil {
+		t.err = parsec.ContextOf(t).Abort(next, err)
+		return nil
+	}
+	if tok == nil {
+		return nil
+	}
+	t.toks = append(t.toks, tok)
+	t.modes = append(t.modes, t.mode)
+	t.ends = append(t.ends, next)
+	return tok
+}
+
+// Err 词法错误, 同解析返回的 *parsec.AbortError, Err 为 Lexer 返回的错误, Pos 为出错的位置
+// 词法错误会中止解析 (不会被 Alt 等回溯), 所以不能依靠 Alt 在多个词法模式之间试探
+func (t *LazyState) Err() error { return t.err }
+
+// Mode 当前的词法模式
+func (t *LazyState) Mode() Mode { return t.mode }
+
+func (t *LazyState) Put(ud interface{}) { t.ud = ud }
+func (t *LazyState) Get() interface{}   { return t.ud }
+
+// WithMode 在 mode 模式下应用 p, 之后恢复原来的模式
+// e.g. Right(Str("`"), WithMode(Template, Many(chunk)))
+func WithMode(mode Mode, p parsec.Parser) parsec.Parser {
+	return parsec.NewParser(func(s parsec.State) (interface{}, error) {
+		t := s.(*LazyState)
+		outer := t.mode
+		t.mode = mode
+		defer func() { t.mode = outer }()
+		return p.Parse(s)
+	})
+}
+
+// ----------------------------------------------------------------
+// Regex Lexer
+// ----------------------------------------------------------------
+
+// NewRegexLexer 基于正则的多模式 Lexer, 每个模式按规则添加的顺序匹配第一个
+func NewRegexLexer() *RegexLexer { return &RegexLexer{rules: map[Mode][]lexRule{}} }
+
+type RegexLexer struct{ rules map[Mode][]lexRule }
+
+type lexRule struct {
+	kind lexer.TokenKind
+	reg  *regexp.Regexp
+	skip bool
+}
+
+func (l *RegexLexer) Regex(mode Mode, kind lexer.TokenKind, reg string) *RegexLexer {
+	return l.add(mode, lexRule{kind: kind, reg: regexp.MustCompile("^(?:" + reg + ")")})
+}
+func (l *RegexLexer) Str(mode Mode, kind lexer.TokenKind, str string) *RegexLexer {
+	return l.Regex(mode, kind, regexp.QuoteMeta(str))
+}
+func (l *RegexLexer) Skip(mode Mode, reg string) *RegexLexer {
+	return l.add(mode, lexRule{reg: regexp.MustCompile("^(?:" + reg + ")"), skip: true})
+}
+func (l *RegexLexer) add(mode Mode, r lexRule) *RegexLexer {
+	l.rules[mode] = append(l.rules[mode], r)
+	return l
+}
+
+func (l *RegexLexer) Lex(src string, at parsec.Pos, mode Mode) (*lexer.Token, parsec.Pos, error) {
+	for at.Idx < len(src) {
+		rest := src[at.Idx:]
+		var r *lexRule
+		n := 0
+		for i := range l.rules[mode] {
+			if loc := l.rules[mode][i].reg.FindStringIndex(rest); loc != nil && loc[1] > 0 {
+				r, n = &l.rules[mode][i], loc[1]
+				break
+			}
+		}
+		if r == nil {
+			c, _ := utf8.DecodeRuneInString(rest)
+			return nil, at, fmt.Errorf("unexpected `%c` in mode %d", c, mode)
+		}
+		start := at
+		at = advance(at, rest[:n])
+		if !r.skip {
+			tok := &lexer.Token{
+				TokenKind: r.kind,
+				Lexeme:    rest[:n],
+				Pos:       lexer.Pos{Idx: start.Idx, Line: start.Line, Col: start.Col},
+			}
+			return tok, at, nil
+		}
+	}
+	return nil, at, nil
+}
+
+func advance(pos parsec.Pos, s string) parsec.Pos {
+	pos.Idx += len(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		pos.Line += strings.Count(s, "\n")
+		pos.Col = utf8.RuneCountInString(s[i+1:])
+	} else {
+		pos.Col += utf8.RuneCountInString(s)
+	}
+	return pos
+}
@@ -1,6 +1,9 @@
 package tokstate
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/goghcrow/lexer"
 	"github.com/goghcrow/parsec"
 )
@@ -9,12 +12,28 @@ import (
 // Token State
 // ----------------------------------------------------------------
 
-func NewState(toks []*lexer.Token) parsec.State { return &TokState{seq: toks} }
+func NewState(toks []*lexer.Token) parsec.State { return &TokState{seq: toks, all: toks} }
+
+// NewStateWithTrivia toks 包含空白注释等 trivia, Next 自动跳过 isTrivia 的 token
+// trivia 挂在相邻的 token 上 (见 Trivia), 可以用 Text FullText Source 还原源码
+func NewStateWithTrivia(toks []*lexer.Token, isTrivia func(*lexer.Token) bool) parsec.State {
+	t := &TokState{all: toks, idx: []int{}}
+	for i, tok := range toks {
+		if !isTrivia(tok) {
+			t.seq = append(t.seq, tok)
+			t.idx = append(t.idx, i)
+		}
+	}
+	return t
+}
 
 type TokState struct {
 	seq []*lexer.Token
+	all []*lexer.Token // 包含 trivia
+	idx []int          // seq[i] == all[idx[i]], 没有 trivia 时为 nil
 	parsec.Pos
 	ud interface{}
+	parsec.ContextHolder
 }
 
 func (t *TokState) Save() parsec.Pos     { return t.Pos }
@@ -34,3 +53,139 @@ func (t *TokState) forward() *lexer.Token {
 }
 func (t *TokState) Put(ud interface{}) { t.ud = ud }
 func (t *TokState) Get() interface{}   { return t.ud }
+
+// ----------------------------------------------------------------
+// Trivia
+// 同 Roslyn: token 之后到换行 (包含) 的 trivia 为 trailing, 其余为下一个 token 的 leading
+// 最后一个 token 之后剩余的 trivia 见 Trivia(len)
+// ----------------------------------------------------------------
+
+// at seq 下标 i 在 all 中的下标, i == len(seq) 时为 len(all)
+func (t *TokState) at(i int) int {
+	if i >= len(t.seq) {
+		return len(t.all)
+	}
+	if t.idx == nil {
+		return i
+	}
+	return t.idx[i]
+}
+
+// trailingEnd seq[i] 的 trailing trivia 在 all 中的结束下标 (不包含)
+func (t *TokState) trailingEnd(i int) int {
+	j, end := t.at(i)+1, t.at(i+1)
+	for ; j < end; j++ {
+		if strings.Contains(t.all[j].Lexeme, "\n") {
+			return j + 1
+		}
+	}
+	return end
+}
+
+// leadingStart seq[i] 的 leading trivia 在 all 中的开始下标
+func (t *TokState) leadingStart(i int) int {
+	if i == 0 {
+		return 0
+	}
+	return t.trailingEnd(i - 1)
+}
+
+// Trivia 第 i 个 token 的 leading trailing trivia
+// i == token 数量时, leading 为文件末尾的 trivia
+func (t *TokState) Trivia(i int) (leading, trailing []*lexer.Token) {
+	if i >= len(t.seq) {
+		return t.all[t.leadingStart(len(t.seq)):], nil
+	}
+	return t.all[t.leadingStart(i):t.at(i)], t.all[t.at(i)+1 : t.trailingEnd(i)]
+}
+
+// TriviaOf 同 Trivia, tok 不在输入中时返回 nil
+func (t *TokState) TriviaOf(tok *lexer.Token) (leading, trailing []*lexer.Token) {
+	for i, x := range t.seq {
+		if x == tok {
+			return t.Trivia(i)
+		}
+	}
+	return nil, nil
+}
+
+// Text [from, to) 范围内的 token 以及其间的 trivia, 不包含首尾的 trivia
+// e.g. Text(sp.Start, sp.End), sp 由 parsec.WithSpan 得到
+func (t *TokState) Text(from, to parsec.Pos) string {
+	if to.Idx <= from.Idx {
+		return ""
+	}
+	return join(t.all[t.at(from.Idx) : t.at(to.Idx-1)+1])
+}
+
+// FullText 同 Text, 包含第一个 token 的 leading trivia 与最后一个 token 的 trailing trivia
+// 相邻范围的 FullText 首尾相接, 可以用来原样输出未修改的节点
+func (t *TokState) FullText(from, to parsec.Pos) string {
+	if to.Idx <= from.Idx {
+		return ""
+	}
+	return join(t.all[t.leadingStart(from.Idx):t.trailingEnd(to.Idx-1)])
+}
+
+// Slice 实现 parsec.Lossless, 包含 from 的 leading trivia 与 to 之前 token 的 trailing trivia
+func (t *TokState) Slice(from, to parsec.Pos) string {
+	return join(t.all[t.boundary(from.Idx):t.boundary(to.Idx)])
+}
+
+// Pieces 实现 parsec.Tokenized, Slice(from, to) 中的每个 token, isTrivia 的 token 为 trivia
+// trivia 不占 Pos, Span 为所在位置的空范围
+func (t *TokState) Pieces(from, to parsec.Pos) []parsec.Piece {
+	i, j := t.boundary(from.Idx), t.boundary(to.Idx)
+	k := from.Idx // 下一个非 trivia token 在 seq 中的下标
+	if t.idx != nil {
+		k = sort.SearchInts(t.idx, i)
+	}
+	var xs []parsec.Piece
+	for ; i < j; i++ {
+		trivia := t.idx != nil && (k >= len(t.idx) || t.idx[k] != i)
+		sp := parsec.Span{Start: t.posAt(k), End: t.posAt(k)}
+		if !trivia {
+			k++
+			sp.End = t.posAt(k)
+		}
+		xs = append(xs, parsec.Piece{Text: t.all[i].Lexeme, Trivia: trivia, Span: sp})
+	}
+	return xs
+}
+
+// posAt 消费 i 个 token 之后的 Pos, 同 Save
+func (t *TokState) posAt(i int) parsec.Pos {
+	if i == 0 {
+		return parsec.Pos{}
+	}
+	tok := t.seq[i-1]
+	return parsec.Pos{Idx: i, Line: tok.Line, Col: tok.Col}
+}
+
+// End 在最后一个 token 之后 (Idx 为 token 数量 + 1), Slice(x, End()) 包含文件末尾的 trivia
+func (t *TokState) End() parsec.Pos {
+	end := parsec.Pos{Idx: len(t.seq) + 1}
+	if len(t.seq) > 0 {
+		end.Line, end.Col = t.seq[len(t.seq)-1].Line, t.seq[len(t.seq)-1].Col
+	}
+	return end
+}
+
+// boundary 第 i 个 token 的 leading trivia 在 all 中的开始下标
+func (t *TokState) boundary(i int) int {
+	if i > len(t.seq) {
+		return len(t.all)
+	}
+	return t.leadingStart(i)
+}
+
+// Source 所有 token 与 trivia, lexer 不丢弃任何输入时与源码相同
+func (t *TokState) Source() string { return join(t.all) }
+
+func join(toks []*lexer.Token) string {
+	var b strings.Builder
+	for _, tok := range toks {
+		b.WriteString(tok.Lexeme)
+	}
+	return b.String()
+}
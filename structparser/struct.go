@@ -0,0 +1,245 @@
+package structparser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	. "github.com/goghcrow/parsec"
+)
+
+// 参考 participle, 由带 tag 的 struct 生成 Parser, 解析结果填充到 struct
+//
+//	type Entry struct {
+//		Key   string `parsec:"@Ident '='"`
+//		Value *Value `parsec:"@@ ';'"`
+//	}
+//
+// @Name 捕获终结符 Terminals[Name], @'x' 捕获字面量, @( ... ) 捕获括号内所有的终结符与字面量
+// @@ 递归解析字段类型的 struct, 'x' Name 只匹配不捕获, ? * + | ( ) 同 EBNF
+//
+// 捕获的值按字段类型转换:
+// string 追加文本, bool 置为 true, 数字用 strconv 解析, slice 追加元素, 指针自动分配,
+// 其他类型要求值可以直接赋值
+//
+// 每个 struct 类型对应一个以类型名命名的 SyntaxRule, 可以配合 Tracer 与 cst 使用
+
+func NewGrammar(terminals map[string]Parser, literal func(string) Parser) *Grammar {
+	return &Grammar{terminals: terminals, literal: literal, rules: map[reflect.Type]*SyntaxRule{}}
+}
+
+// Grammar 不是并发安全的, Build 完成之后得到的 Parser 可以共享
+type Grammar struct {
+	terminals map[string]Parser   // tag 中的 Name
+	literal   func(string) Parser // tag 中的 'x', e.g. func(s string) Parser { return Trim(Str(s), Space) }
+	rules     map[reflect.Type]*SyntaxRule
+}
+
+// Build 返回解析 proto 类型 (struct 或 struct 指针) 的 Parser, 结果为 *T
+// tag 非法, 终结符未定义或者字段类型不支持时 panic
+func (g *Grammar) Build(proto interface{}) Parser {
+	t := reflect.TypeOf(proto)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("structparser: expect struct actual %T", proto))
+	}
+	return g.rule(t)
+}
+
+func (g *Grammar) rule(t reflect.Type) *SyntaxRule {
+	if r, ok := g.rules[t]; ok {
+		return r
+	}
+	name := t.Name()
+	if name == "" {
+		name = t.String() // 匿名 struct
+	}
+	r := NewNamedRule(name)
+	g.rules[t] = r // 先注册, 支持递归的类型
+	defer func() {
+		if err := recover(); err != nil {
+			delete(g.rules, t)
+			panic(err)
+		}
+	}()
+
+	tags := make([]string, t.NumField())
+	for i := range tags {
+		tags[i] = t.Field(i).Tag.Get("parsec")
+	}
+	e, err := parseTags(tags)
+	if err != nil {
+		panic(fmt.Sprintf("structparser: %s: %s", name, err))
+	}
+	seq := g.compile(name, t, e)
+
+	r.Pattern = NewParser(func(s State) (interface{}, error) {
+		v, err := seq.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		ptr := reflect.New(t)
+		for _, a := range flatten(v, nil) {
+			if err := assign(ptr.Elem().Field(a.field), a.v); err != nil {
+				// 语法已经匹配, 转换失败中止整个解析, 不应该被 Alt Many 等回溯吞掉
+				err = fmt.Errorf("%s.%s: %s", name, t.Field(a.field).Name, err)
+				if c := ContextOf(s); c != nil {
+					return nil, c.Abort(a.pos, err)
+				}
+				return nil, &AbortError{Pos: a.pos, Err: err}
+			}
+		}
+		return ptr.Interface(), nil
+	})
+	return r
+}
+
+// action 解析成功之后才写入字段, 回溯的分支不会留下部分结果
+type action struct {
+	pos   Pos
+	field int
+	v     interface{}
+}
+
+func (g *Grammar) compile(name string, t reflect.Type, e *expr) Parser {
+	compileAll := func() []Parser {
+		ps := make([]Parser, len(e.xs))
+		for i, x := range e.xs {
+			ps[i] = g.compile(name, t, x)
+		}
+		return ps
+	}
+	switch e.kind {
+	case kSeq:
+		return List(compileAll()...)
+	case kAlt:
+		return Alt(compileAll()...)
+	case kOpt:
+		return Option(g.compile(name, t, e.xs[0]), nil)
+	case kMany:
+		return Many(g.compile(name, t, e.xs[0]))
+	case kMany1:
+		return Many1(g.compile(name, t, e.xs[0]))
+	case kLit:
+		if g.literal == nil {
+			panic(fmt.Sprintf("structparser: %s: literal '%s' without literal func", name, e.text))
+		}
+		return g.captured(e, g.literal(e.text))
+	case kTerm:
+		p, ok := g.terminals[e.text]
+		if !ok {
+			panic(fmt.Sprintf("structparser: %s: undefined terminal `%s`", name, e.text))
+		}
+		return g.captured(e, p)
+	case kSelf:
+		f := t.Field(e.field)
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("structparser: %s.%s: @@ requires struct field", name, f.Name))
+		}
+		return g.captured(e, g.rule(ft))
+	default:
+		panic("unreached")
+	}
+}
+
+func (g *Grammar) captured(e *expr, p Parser) Parser {
+	if !e.capture {
+		return Right(p, Nil)
+	}
+	return WithSpan(p).Map(func(v interface{}) interface{} {
+		sp := v.(Spanned)
+		return action{sp.Start, e.field, sp.Value}
+	})
+}
+
+// flatten 收集 List Many 等嵌套结果中的 action
+func flatten(v interface{}, acc []action) []action {
+	switch x := v.(type) {
+	case action:
+		return append(acc, x)
+	case []interface{}:
+		for _, it := range x {
+			acc = flatten(it, acc)
+		}
+	}
+	return acc
+}
+
+func assign(f reflect.Value, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(f.String() + text(v))
+		return nil
+	case reflect.Bool:
+		f.SetBool(true)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text(v), 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text(v), 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text(v), f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+		return nil
+	}
+	if rv.IsValid() && rv.Type().AssignableTo(f.Type()) {
+		f.Set(rv)
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.Ptr:
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		return assign(f.Elem(), v)
+	case reflect.Slice:
+		elem := reflect.New(f.Type().Elem()).Elem()
+		if err := assign(elem, v); err != nil {
+			return err
+		}
+		f.Set(reflect.Append(f, elem))
+		return nil
+	case reflect.Struct:
+		if rv.Kind() == reflect.Ptr && rv.Elem().Type() == f.Type() {
+			f.Set(rv.Elem())
+			return nil
+		}
+	}
+	return fmt.Errorf("can not assign %T to %s", v, f.Type())
+}
+
+// text 捕获值的文本, e.g. string rune token, Many(Letter) 的 []interface{}
+func text(v interface{}) string {
+	if xs, ok := v.([]interface{}); ok {
+		var b strings.Builder
+		for _, x := range xs {
+			b.WriteString(text(x))
+		}
+		return b.String()
+	}
+	if v == nil {
+		return ""
+	}
+	return Show(v)
+}
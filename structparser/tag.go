@@ -0,0 +1,221 @@
+package structparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ----------------------------------------------------------------
+// Tag Grammar
+// struct 所有字段的 tag 依次拼接成一个文法, 捕获 (@) 写入所在 tag 的字段
+// 所以可以在字段之间写分支, e.g. `parsec:"@Int"` `parsec:"| @String"`
+//
+//	alt     = seq { "|" seq }
+//	seq     = { postfix }
+//	postfix = atom [ "?" | "*" | "+" ]
+//	atom    = "@@" | "@" atom | ident | literal | "(" alt ")"
+//	literal = "'" ... "'" | '"' ... '"'
+// ----------------------------------------------------------------
+
+type exprKind int
+
+const (
+	kSeq exprKind = iota
+	kAlt
+	kOpt
+	kMany
+	kMany1
+	kLit  // 'x'
+	kTerm // Ident
+	kSelf // @@
+)
+
+type expr struct {
+	kind    exprKind
+	text    string // kLit kTerm
+	capture bool   // kLit kTerm kSelf
+	field   int    // 捕获写入的字段下标
+	xs      []*expr
+}
+
+type token struct {
+	text  string
+	field int
+}
+
+type tagParser struct {
+	toks []token
+	i    int
+}
+
+// parseTags tags[i] 为第 i 个字段的 tag, 空 tag 的字段忽略
+func parseTags(tags []string) (*expr, error) {
+	var toks []token
+	for field, tag := range tags {
+		xs, err := tokenize(tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range xs {
+			toks = append(toks, token{x, field})
+		}
+	}
+	p := &tagParser{toks: toks}
+	e, err := p.alt()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.toks) {
+		return nil, fmt.Errorf("unexpected `%s`", p.toks[p.i].text)
+	}
+	return e, nil
+}
+
+func tokenize(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '@' && i+1 < len(s) && s[i+1] == '@':
+			toks = append(toks, "@@")
+			i += 2
+		case strings.IndexByte("@|?*+()", c) >= 0:
+			toks = append(toks, s[i:i+1])
+			i++
+		case c == '\'' || c == '"':
+			j := strings.IndexByte(s[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated literal %s", s[i:])
+			}
+			toks = append(toks, s[i:i+j+2])
+			i += j + 2
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected `%c`", c)
+		}
+	}
+	return toks, nil
+}
+
+func (p *tagParser) peek() string {
+	if p.i < len(p.toks) {
+		return p.toks[p.i].text
+	}
+	return ""
+}
+
+func (p *tagParser) alt() (*expr, error) {
+	var xs []*expr
+	for {
+		e, err := p.seq()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, e)
+		if p.peek() != "|" {
+			break
+		}
+		p.i++
+	}
+	if len(xs) == 1 {
+		return xs[0], nil
+	}
+	return &expr{kind: kAlt, xs: xs}, nil
+}
+
+func (p *tagParser) seq() (*expr, error) {
+	var xs []*expr
+	for t := p.peek(); t != "" && t != "|" && t != ")"; t = p.peek() {
+		e, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, e)
+	}
+	if len(xs) == 0 {
+		return nil, fmt.Errorf("empty sequence")
+	}
+	if len(xs) == 1 {
+		return xs[0], nil
+	}
+	return &expr{kind: kSeq, xs: xs}, nil
+}
+
+func (p *tagParser) postfix() (*expr, error) {
+	e, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	kinds := map[string]exprKind{"?": kOpt, "*": kMany, "+": kMany1}
+	if k, ok := kinds[p.peek()]; ok {
+		p.i++
+		e = &expr{kind: k, xs: []*expr{e}}
+	}
+	return e, nil
+}
+
+func (p *tagParser) atom() (*expr, error) {
+	t, field := p.peek(), 0
+	if p.i < len(p.toks) {
+		field = p.toks[p.i].field
+	}
+	p.i++
+	switch {
+	case t == "@@":
+		return &expr{kind: kSelf, capture: true, field: field}, nil
+	case t == "@":
+		e, err := p.atom()
+		if err != nil {
+			return nil, err
+		}
+		if err := capture(e, field); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case t == "(":
+		e, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("expect `)`")
+		}
+		p.i++
+		return e, nil
+	case t != "" && (t[0] == '\'' || t[0] == '"'):
+		return &expr{kind: kLit, text: t[1 : len(t)-1]}, nil
+	case t != "" && strings.IndexByte("|?*+)", t[0]) < 0:
+		return &expr{kind: kTerm, text: t}, nil
+	default:
+		if t == "" {
+			return nil, fmt.Errorf("unexpected end of tag")
+		}
+		return nil, fmt.Errorf("unexpected `%s`", t)
+	}
+}
+
+// capture 捕获 e 中匹配的所有终结符与字面量, e.g. @('+' | '-')
+func capture(e *expr, field int) error {
+	switch e.kind {
+	case kLit, kTerm:
+		e.capture, e.field = true, field
+	case kSelf:
+		return fmt.Errorf("`@` can not be followed by `@@`")
+	default:
+		for _, x := range e.xs {
+			if err := capture(x, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
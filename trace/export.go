@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// Export
+// ----------------------------------------------------------------
+
+var colors = map[Status]string{
+	StatusOK:          "#c8e6c9",
+	StatusFail:        "#ffcdd2",
+	StatusBacktracked: "#e0e0e0",
+}
+
+// WriteDOT 输出 Graphviz DOT, 成功为绿色, 失败为红色, 被回溯为灰色
+// e.g. dot -Tsvg trace.dot > trace.svg
+func (t *Trace) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph parse {\n")
+	b.WriteString("  node [shape=box, style=filled, fontname=\"monospace\"];\n")
+	id := 0
+	var visit func(n *Node) int
+	visit = func(n *Node) int {
+		self := id
+		id++
+		label := fmt.Sprintf("%s\n%d-%d", n.Rule, n.Start.Idx, n.End.Idx)
+		if n.Err != "" {
+			label += "\n" + n.Err
+		}
+		fmt.Fprintf(&b, "  n%d [label=%s, fillcolor=%q];\n", self, strconv.Quote(label), colors[n.Status])
+		for _, c := range n.Children {
+			fmt.Fprintf(&b, "  n%d -> n%d;\n", self, visit(c))
+		}
+		return self
+	}
+	visit(t.Root)
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type jsonPos struct {
+	Idx  int `json:"idx"`
+	Line int `json:"line"`
+	Col  int `json:"col"`
+}
+
+type jsonNode struct {
+	Rule     string      `json:"rule"`
+	Status   Status      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Start    jsonPos     `json:"start"`
+	End      jsonPos     `json:"end"`
+	From     int         `json:"from"` // Source 中的 rune 偏移
+	To       int         `json:"to"`
+	Children []*jsonNode `json:"children,omitempty"`
+}
+
+// WriteJSON 输出 {"source": ..., "root": node}, Pos 从 0 开始, from to 为 source 中的 rune 偏移
+func (t *Trace) WriteJSON(w io.Writer) error {
+	var conv func(n *Node) *jsonNode
+	conv = func(n *Node) *jsonNode {
+		j := &jsonNode{
+			Rule:   n.Rule,
+			Status: n.Status,
+			Error:  n.Err,
+			Start:  jsonPos{n.Start.Idx, n.Start.Line, n.Start.Col},
+			End:    jsonPos{n.End.Idx, n.End.Line, n.End.Col},
+			From:   n.from,
+			To:     n.to,
+		}
+		for _, c := range n.Children {
+			j.Children = append(j.Children, conv(c))
+		}
+		return j
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Source string    `json:"source"`
+		Root   *jsonNode `json:"root"`
+	}{t.Source, conv(t.Root)})
+}
+
+// WriteHTML 输出独立的 HTML 页面, 节点可以折叠, 鼠标悬停时在源码中高亮匹配范围
+func (t *Trace) WriteHTML(w io.Writer) error {
+	return page.Execute(w, t)
+}
+
+// Label 规则名, 匹配范围以及错误, 用于展示
+func (n *Node) Label() string {
+	s := fmt.Sprintf("%s %d-%d", n.Rule, n.Start.Idx, n.End.Idx)
+	if n.Err != "" {
+		s += ": " + n.Err
+	}
+	return s
+}
+
+// From To 匹配范围在 Trace.Source 中的 rune 偏移
+func (n *Node) From() int { return n.from }
+func (n *Node) To() int   { return n.to }
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>parse trace</title>
+<style>
+body { font-family: monospace; display: flex; gap: 2em; margin: 1em; }
+#src { white-space: pre-wrap; border: 1px solid #ccc; padding: .5em; flex: 1; align-self: flex-start; position: sticky; top: 1em; }
+#tree { flex: 1; }
+details { margin-left: 1.2em; }
+summary { cursor: pointer; padding: 1px 4px; }
+summary.leaf { list-style: none; }
+.ok > summary { background: #c8e6c9; }
+.fail > summary { background: #ffcdd2; }
+.backtracked > summary { background: #e0e0e0; text-decoration: line-through; }
+mark { background: #fff59d; }
+</style>
+</head>
+<body>
+<pre id="src"></pre>
+<div id="tree">{{template "node" .Root}}</div>
+<script>
+const src = Array.from({{.Source}});
+const pre = document.getElementById("src");
+function show(from, to) {
+  pre.textContent = "";
+  pre.append(src.slice(0, from).join(""));
+  const m = document.createElement("mark");
+  m.textContent = src.slice(from, to).join("");
+  pre.append(m, src.slice(to).join(""));
+}
+show(0, 0);
+document.querySelectorAll("summary").forEach(s => {
+  const d = s.parentElement;
+  s.addEventListener("mouseenter", () => show(+d.dataset.from, +d.dataset.to));
+});
+</script>
+</body>
+</html>
+{{define "node"}}<details open class="{{.Status}}" data-from="{{.From}}" data-to="{{.To}}"><summary{{if not .Children}} class="leaf"{{end}}>{{.Label}}</summary>{{range .Children}}{{template "node" .}}{{end}}</details>
+{{end}}`))
@@ -0,0 +1,135 @@
+package trace
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/goghcrow/parsec"
+)
+
+// ----------------------------------------------------------------
+// Parse Trace
+// 通过 Tracer 记录每个命名 SyntaxRule 的尝试: 在哪里开始, 成功, 失败, 或者成功之后又被回溯
+// 可以导出为 DOT JSON 以及 HTML
+// ----------------------------------------------------------------
+
+const KindRoot = "#root" // Record 返回的根节点
+
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusFail        Status = "fail"
+	StatusBacktracked Status = "backtracked" // 成功之后被外层的 Try 回溯, 结果被丢弃
+)
+
+type Node struct {
+	Rule string // SyntaxRule.Name 或 KindRoot
+	parsec.Span
+	Status   Status
+	Err      string
+	Children []*Node
+
+	from, to int // 在 Trace.Source 中的 rune 偏移, 用于高亮
+}
+
+// Trace Record 的结果
+type Trace struct {
+	Source string // 从开始位置到输入结束的源码
+	Root   *Node
+}
+
+// Record 用 p 解析 s 并记录命名规则的尝试, 返回 p 的结果
+// s 必须实现 Lossless 与 Contextual, 已有的 Tracer 会继续收到调用
+// 注意: Memo 命中缓存时不经过 Tracer, 不会出现在 trace 中
+func Record(p parsec.Parser, s parsec.State) (*Trace, interface{}, error) {
+	src, ok := s.(parsec.Lossless)
+	if !ok {
+		panic(fmt.Sprintf("trace: %T does not implement parsec.Lossless", s))
+	}
+	c := parsec.ContextOf(s)
+	if c == nil {
+		panic(fmt.Sprintf("trace: %T does not implement parsec.Contextual", s))
+	}
+	start := s.Save()
+	root := &Node{Rule: KindRoot, Span: parsec.Span{Start: start}}
+	r := &recorder{next: c.Tracer, stack: []*Node{root}}
+	c.Tracer = r
+	defer func() { c.Tracer = r.next }()
+
+	v, err := parsec.Run(c, p, s)
+
+	root.End = s.Save()
+	root.Status = StatusOK
+	if err != nil {
+		root.Status, root.Err = StatusFail, err.Error()
+	}
+	t := &Trace{Source: src.Slice(start, src.End()), Root: root}
+	offsets := map[int]int{}
+	offset := func(pos parsec.Pos) int {
+		if off, ok := offsets[pos.Idx]; ok {
+			return off
+		}
+		off := utf8.RuneCountInString(src.Slice(start, pos))
+		offsets[pos.Idx] = off
+		return off
+	}
+	t.Root.walk(func(n *Node) { n.from, n.to = offset(n.Start), offset(n.End) })
+	return t, v, err
+}
+
+func (n *Node) walk(f func(*Node)) {
+	f(n)
+	for _, c := range n.Children {
+		c.walk(f)
+	}
+}
+
+type recorder struct {
+	next  parsec.Tracer
+	stack []*Node // 正在尝试的规则, stack[0] 为根
+}
+
+func (r *recorder) Enter(rule *parsec.SyntaxRule, pos parsec.Pos) {
+	if rule.Name != "" {
+		n := &Node{Rule: rule.Name, Span: parsec.Span{Start: pos, End: pos}}
+		parent := r.stack[len(r.stack)-1]
+		parent.Children = append(parent.Children, n)
+		r.stack = append(r.stack, n)
+	}
+	if r.next != nil {
+		r.next.Enter(rule, pos)
+	}
+}
+
+func (r *recorder) Exit(rule *parsec.SyntaxRule, pos parsec.Pos, v interface{}, err error) {
+	if r.next != nil {
+		r.next.Exit(rule, pos, v, err)
+	}
+	if rule.Name == "" {
+		return
+	}
+	n := r.stack[len(r.stack)-1]
+	r.stack = r.stack[:len(r.stack)-1]
+	n.End = pos
+	n.Status = StatusOK
+	if err != nil {
+		n.Status, n.Err = StatusFail, err.Error()
+	}
+}
+
+// Backtrack 当前规则中已经成功, 但匹配范围在恢复位置之后的尝试被丢弃
+func (r *recorder) Backtrack(rule *parsec.SyntaxRule, from, to parsec.Pos, err error) {
+	if bt, ok := r.next.(parsec.BacktrackTracer); ok {
+		bt.Backtrack(rule, from, to, err)
+	}
+	for _, c := range r.stack[len(r.stack)-1].Children {
+		if c.Status == StatusOK && c.Start.Idx >= to.Idx && c.End.Idx > to.Idx {
+			c.walk(func(n *Node) {
+				if n.Status == StatusOK {
+					n.Status = StatusBacktracked
+				}
+			})
+		}
+	}
+}